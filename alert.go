@@ -0,0 +1,110 @@
+package darksky
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity of an alert.
+type Severity string
+
+const (
+	// SeverityAdvisory an individual should be aware of potentially severe weather.
+	SeverityAdvisory Severity = "advisory"
+	// SeverityWatch an individual should prepare for potentially severe weather.
+	SeverityWatch Severity = "watch"
+	// SeverityWarning an individual should take immediate action to protect themselves and others
+	// from potentially severe weather.
+	SeverityWarning Severity = "warning"
+)
+
+var severityLevels = map[Severity]int{
+	SeverityAdvisory: 1,
+	SeverityWatch:    2,
+	SeverityWarning:  3,
+}
+
+// Level of the severity, from 1 for an advisory to 3 for a warning, and 0 for an unknown severity.
+func (s Severity) Level() int {
+	return severityLevels[Severity(strings.ToLower(string(s)))]
+}
+
+// Less tells whether the severity is lower than o. Unknown severities are the lowest.
+func (s Severity) Less(o Severity) bool {
+	return s.Level() < o.Level()
+}
+
+// ActiveAt tells whether the alert is in effect at t, between its Time and its Expires, when it
+// has one.
+func (a Alert) ActiveAt(t time.Time) bool {
+	ts := t.Unix()
+
+	return a.Time <= ts && (a.Expires == 0 || ts < a.Expires)
+}
+
+// Alerts is a list of alerts, with helpers to filter and sort them.
+type Alerts []Alert
+
+// Filter returns the alerts for which keep is true.
+func (a Alerts) Filter(keep func(Alert) bool) Alerts {
+	var filtered Alerts
+
+	for _, alert := range a {
+		if keep(alert) {
+			filtered = append(filtered, alert)
+		}
+	}
+
+	return filtered
+}
+
+// MinSeverity returns the alerts of severity s or higher.
+func (a Alerts) MinSeverity(s Severity) Alerts {
+	return a.Filter(func(alert Alert) bool {
+		return !alert.Severity.Less(s)
+	})
+}
+
+// ActiveAt returns the alerts in effect at t.
+func (a Alerts) ActiveAt(t time.Time) Alerts {
+	return a.Filter(func(alert Alert) bool {
+		return alert.ActiveAt(t)
+	})
+}
+
+// InRegion returns the alerts covering a region, compared regardless of case.
+func (a Alerts) InRegion(region string) Alerts {
+	return a.Filter(func(alert Alert) bool {
+		for _, r := range alert.Regions {
+			if strings.EqualFold(r, region) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// WithKeyword returns the alerts whose title contains the keyword, regardless of case.
+func (a Alerts) WithKeyword(keyword string) Alerts {
+	keyword = strings.ToLower(keyword)
+
+	return a.Filter(func(alert Alert) bool {
+		return strings.Contains(strings.ToLower(alert.Title), keyword)
+	})
+}
+
+// Sort sorts the alerts from the most severe, then from the first to expire, alerts without
+// expiry being last. The order of equal alerts is kept.
+func (a Alerts) Sort() {
+	sort.SliceStable(a, func(i, j int) bool {
+		if li, lj := a[i].Severity.Level(), a[j].Severity.Level(); li != lj {
+			return li > lj
+		}
+
+		ei, ej := a[i].Expires, a[j].Expires
+
+		return ei != 0 && (ej == 0 || ei < ej)
+	})
+}
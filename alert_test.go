@@ -0,0 +1,70 @@
+package darksky
+
+import (
+	"testing"
+	"time"
+)
+
+var testAlerts = Alerts{
+	{Title: "Flood Watch", Severity: SeverityWatch, Regions: []string{"Suffolk"}, Time: 100, Expires: 400},
+	{Title: "Wind Advisory", Severity: SeverityAdvisory, Regions: []string{"Suffolk", "Norfolk"}, Time: 100, Expires: 200},
+	{Title: "Winter Storm Warning", Severity: SeverityWarning, Regions: []string{"Norfolk"}, Time: 300},
+	{Title: "Special Weather Statement", Severity: "statement", Regions: []string{"Essex"}, Time: 100, Expires: 500},
+	{Title: "Coastal Flood Warning", Severity: "Warning", Regions: []string{"Essex"}, Time: 100, Expires: 250},
+}
+
+func titles(a Alerts) []string {
+	var t []string
+
+	for _, alert := range a {
+		t = append(t, alert.Title)
+	}
+
+	return t
+}
+
+func assertTitles(t *testing.T, name string, a Alerts, expected ...string) {
+	got := titles(a)
+
+	if len(got) != len(expected) {
+		t.Errorf("%s expected %v, got %v", name, expected, got)
+		return
+	}
+
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("%s expected %v, got %v", name, expected, got)
+			return
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	if !SeverityAdvisory.Less(SeverityWatch) || !SeverityWatch.Less(SeverityWarning) {
+		t.Error("Severities should be ordered advisory < watch < warning")
+	}
+
+	if !Severity("statement").Less(SeverityAdvisory) {
+		t.Error("Unknown severities should be the lowest")
+	}
+
+	assertInt(t, "Warning level", int64(Severity("WARNING").Level()), 3)
+}
+
+func TestAlertsFilters(t *testing.T) {
+	assertTitles(t, "MinSeverity", testAlerts.MinSeverity(SeverityWatch), "Flood Watch", "Winter Storm Warning", "Coastal Flood Warning")
+	assertTitles(t, "ActiveAt", testAlerts.ActiveAt(time.Unix(200, 0)), "Flood Watch", "Special Weather Statement", "Coastal Flood Warning")
+	assertTitles(t, "ActiveAt without expiry", testAlerts.ActiveAt(time.Unix(1000, 0)), "Winter Storm Warning")
+	assertTitles(t, "InRegion", testAlerts.InRegion("norfolk"), "Wind Advisory", "Winter Storm Warning")
+	assertTitles(t, "WithKeyword", testAlerts.WithKeyword("FLOOD"), "Flood Watch", "Coastal Flood Warning")
+	assertTitles(t, "chained", testAlerts.InRegion("Essex").MinSeverity(SeverityAdvisory), "Coastal Flood Warning")
+}
+
+func TestAlertsSort(t *testing.T) {
+	a := append(Alerts(nil), testAlerts...)
+
+	a.Sort()
+
+	assertTitles(t, "Sort", a, "Coastal Flood Warning", "Winter Storm Warning", "Flood Watch", "Wind Advisory", "Special Weather Statement")
+	assertString(t, "original", testAlerts[0].Title, "Flood Watch")
+}
@@ -0,0 +1,278 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const backfillDayLayout = "2006-01-02"
+
+// ErrInvalidBackfill occurs when creating a backfill without checkpoint path or sink, with an end
+// before its start, or with sites without a unique ID usable as a file name.
+var ErrInvalidBackfill = errors.New("backfill configuration provided is invalid")
+
+// BackfillSite is a location to backfill.
+type BackfillSite struct {
+	// ID identifies the site in the checkpoint and the sink, it must be unique. It names the
+	// directory of the site in a DirSink, so it cannot contain path separators or "..".
+	ID  string
+	Lat float64
+	Lng float64
+	// Location sets the day boundaries of the site. Defaults to UTC.
+	Location *time.Location
+}
+
+// BackfillSink receives the response of each day of each site.
+type BackfillSink interface {
+	Write(site BackfillSite, day time.Time, data *APIData) error
+}
+
+// BackfillConfig describes a backfill.
+type BackfillConfig struct {
+	Sites []BackfillSite
+	// Start and End are the first and last days to backfill, both included.
+	Start time.Time
+	End   time.Time
+	// Options passed to each time machine query.
+	Options []Option
+	// CheckpointPath is the file the progress is saved to, and resumed from.
+	CheckpointPath string
+	// CallsPerDay is the budget of API calls the backfill can use per UTC day, 0 for no limit.
+	// Once spent, the backfill waits for the next day.
+	CallsPerDay int
+	// Sink receives each day of data. Defaults to a DirSink in Dir.
+	Sink BackfillSink
+	// Dir is where the default sink writes.
+	Dir string
+}
+
+// Backfill fetches the history of several sites, one time machine query per day, saving its
+// progress so it resumes where it left off when run again.
+type Backfill struct {
+	api        *API
+	config     BackfillConfig
+	checkpoint *backfillCheckpoint
+	quota      *Quota
+	now        func() time.Time
+	sleep      func(context.Context, time.Duration) error
+}
+
+// backfillCheckpoint is the progress of a backfill: the last day written for each site, and the
+// calls made today. It is also the store of the quota enforcing the budget.
+type backfillCheckpoint struct {
+	path  string
+	Sites map[string]string `json:"sites"`
+	Quota QuotaState        `json:"quota"`
+}
+
+// validSiteID reports whether id is a single, non-empty path element.
+func validSiteID(id string) bool {
+	return id != "" && id != "." && !strings.Contains(id, "..") &&
+		!strings.ContainsAny(id, `/\`+string(os.PathSeparator))
+}
+
+// NewBackfill creates a backfill, loading the checkpoint if it exists.
+func NewBackfill(api *API, c BackfillConfig) (*Backfill, error) {
+	if api == nil || c.CheckpointPath == "" || c.End.Before(c.Start) || c.CallsPerDay < 0 {
+		return nil, ErrInvalidBackfill
+	}
+
+	ids := make(map[string]bool)
+
+	for _, s := range c.Sites {
+		if !validSiteID(s.ID) || ids[s.ID] {
+			return nil, ErrInvalidBackfill
+		}
+
+		ids[s.ID] = true
+	}
+
+	if c.Sink == nil {
+		if c.Dir == "" {
+			return nil, ErrInvalidBackfill
+		}
+
+		c.Sink = NewDirSink(c.Dir)
+	}
+
+	cp, err := loadBackfillCheckpoint(c.CheckpointPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Backfill{
+		api:        api,
+		config:     c,
+		checkpoint: cp,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+
+	if c.CallsPerDay > 0 {
+		if b.quota, err = NewQuota(QuotaConfig{Hard: c.CallsPerDay, Store: cp}); err != nil {
+			return nil, err
+		}
+
+		b.quota.setDefaultLogger(api.logger)
+	}
+
+	return b, nil
+}
+
+// Run backfills the sites one after the other, day by day, until done or until an error occurs.
+// The progress is saved after each day, so Run can be called again to resume.
+func (b *Backfill) Run(ctx context.Context) error {
+	for _, site := range b.config.Sites {
+		for _, day := range b.remainingDays(site) {
+			if err := b.waitBudget(ctx); err != nil {
+				return err
+			}
+
+			data, err := b.api.TimeMachineContext(ctx, site.Lat, site.Lng, day, b.config.Options...)
+
+			if err != nil {
+				return err
+			}
+
+			if err := b.config.Sink.Write(site, day, data); err != nil {
+				return err
+			}
+
+			b.checkpoint.Sites[site.ID] = day.Format(backfillDayLayout)
+
+			if err := b.checkpoint.save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// remainingDays returns noon of each day left to backfill for the site.
+func (b *Backfill) remainingDays(site BackfillSite) []time.Time {
+	loc := site.Location
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	days := rangeDays(b.config.Start, b.config.End, loc)
+
+	last, err := time.ParseInLocation(backfillDayLayout, b.checkpoint.Sites[site.ID], loc)
+
+	if err != nil {
+		return days
+	}
+
+	for i, d := range days {
+		if d.After(last.Add(day)) {
+			return days[i:]
+		}
+	}
+
+	return nil
+}
+
+// waitBudget counts the call about to be made, waiting for the budget to reset when spent.
+func (b *Backfill) waitBudget(ctx context.Context) error {
+	if b.quota == nil {
+		return nil
+	}
+
+	for {
+		err := b.quota.reserve()
+
+		if err != ErrBudgetExhausted {
+			return err
+		}
+
+		b.api.logger.Printf("backfill budget of %d calls spent, waiting until %s",
+			b.config.CallsPerDay, b.quota.ResetTime().Format(time.RFC3339))
+
+		if err := b.sleep(ctx, b.quota.ResetTime().Sub(b.now())); err != nil {
+			return err
+		}
+	}
+}
+
+func loadBackfillCheckpoint(path string) (*backfillCheckpoint, error) {
+	cp := &backfillCheckpoint{path: path, Sites: make(map[string]string)}
+
+	content, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, cp); err != nil {
+		return nil, err
+	}
+
+	if cp.Sites == nil {
+		cp.Sites = make(map[string]string)
+	}
+
+	return cp, nil
+}
+
+func (cp *backfillCheckpoint) save() error {
+	content, err := json.MarshalIndent(cp, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(cp.path, content)
+}
+
+// Load implements QuotaStore.
+func (cp *backfillCheckpoint) Load() (QuotaState, error) {
+	return cp.Quota, nil
+}
+
+// Save implements QuotaStore.
+func (cp *backfillCheckpoint) Save(s QuotaState) error {
+	cp.Quota = s
+
+	return cp.save()
+}
+
+// DirSink writes each day of data as a JSON file named after the day, in a directory per site:
+// <dir>/<site ID>/<YYYY-MM-DD>.json.
+type DirSink struct {
+	Dir string
+}
+
+// NewDirSink creates a sink writing in dir.
+func NewDirSink(dir string) *DirSink {
+	return &DirSink{Dir: dir}
+}
+
+// Write saves the data of the day for the site.
+func (s *DirSink) Write(site BackfillSite, day time.Time, data *APIData) error {
+	dir := filepath.Join(s.Dir, site.ID)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, day.Format(backfillDayLayout)+".json"), content)
+}
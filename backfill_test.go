@@ -0,0 +1,207 @@
+package darksky
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// HTTPClientFailingMock fails every request after the first ok ones.
+type HTTPClientFailingMock struct {
+	next     HTTPClient
+	ok       int
+	requests int
+}
+
+func (c *HTTPClientFailingMock) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+
+	if c.requests > c.ok {
+		return nil, errors.New("connection refused")
+	}
+
+	return c.next.Do(req)
+}
+
+func newTestBackfill(t *testing.T, client HTTPClient, dir string, callsPerDay int) *Backfill {
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBackfill(api, BackfillConfig{
+		Sites: []BackfillSite{
+			{ID: "boston", Lat: 42.3601, Lng: -71.0589},
+			{ID: "montreal", Lat: 45.5017, Lng: -73.5673},
+		},
+		Start:          time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2019, 3, 3, 0, 0, 0, 0, time.UTC),
+		CheckpointPath: filepath.Join(dir, "checkpoint.json"),
+		CallsPerDay:    callsPerDay,
+		Dir:            filepath.Join(dir, "data"),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func newBackfillDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "darksky-backfill")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func assertBackfillFiles(t *testing.T, dir string) {
+	for _, site := range []string{"boston", "montreal"} {
+		for _, day := range []string{"2019-03-01", "2019-03-02", "2019-03-03"} {
+			if _, err := os.Stat(filepath.Join(dir, "data", site, day+".json")); err != nil {
+				t.Errorf("Day %s of %s should have been written: %s", day, site, err)
+			}
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	dir, cleanup := newBackfillDir(t)
+	defer cleanup()
+
+	client := &HTTPClientDaysMock{loc: time.UTC}
+
+	if err := newTestBackfill(t, client, dir, 0).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(client.times)), 6)
+	assertBackfillFiles(t, dir)
+
+	cp, err := loadBackfillCheckpoint(filepath.Join(dir, "checkpoint.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "boston", cp.Sites["boston"], "2019-03-03")
+	assertString(t, "montreal", cp.Sites["montreal"], "2019-03-03")
+
+	if err := newTestBackfill(t, client, dir, 0).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(client.times)), 6)
+}
+
+func TestBackfillResume(t *testing.T) {
+	dir, cleanup := newBackfillDir(t)
+	defer cleanup()
+
+	days := &HTTPClientDaysMock{loc: time.UTC}
+	failing := &HTTPClientFailingMock{next: days, ok: 4}
+
+	if err := newTestBackfill(t, failing, dir, 0).Run(context.Background()); err == nil {
+		t.Fatal("Backfill should have stopped on the error")
+	}
+
+	if err := newTestBackfill(t, days, dir, 0).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(days.times)), 6)
+	assertBackfillFiles(t, dir)
+
+	for i := 1; i < len(days.times); i++ {
+		if days.times[i].Equal(days.times[i-1]) {
+			t.Errorf("Day %s should not have been fetched twice", days.times[i])
+		}
+	}
+}
+
+func TestBackfillBudget(t *testing.T) {
+	dir, cleanup := newBackfillDir(t)
+	defer cleanup()
+
+	client := &HTTPClientDaysMock{loc: time.UTC}
+	b := newTestBackfill(t, client, dir, 4)
+	clock := &fakeClock{time.Date(2019, 4, 1, 10, 0, 0, 0, time.UTC)}
+
+	var waits []time.Duration
+
+	b.now = clock.now
+	b.quota.now = clock.now
+	b.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		clock.t = clock.t.Add(d)
+
+		return nil
+	}
+
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(client.times)), 6)
+	assertInt(t, "waits", int64(len(waits)), 1)
+	assertInt(t, "wait", int64(waits[0]), int64(14*time.Hour))
+
+	cp, err := loadBackfillCheckpoint(filepath.Join(dir, "checkpoint.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "Quota.Calls", int64(cp.Quota.Calls), 2)
+}
+
+func TestBackfillBudgetCancelled(t *testing.T) {
+	dir, cleanup := newBackfillDir(t)
+	defer cleanup()
+
+	b := newTestBackfill(t, &HTTPClientDaysMock{loc: time.UTC}, dir, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Should have return context.DeadlineExceeded while waiting for the budget, got %v", err)
+	}
+}
+
+func TestErrInvalidBackfill(t *testing.T) {
+	api, err := NewAPI("test-secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	configs := []BackfillConfig{
+		{Dir: "data"},
+		{CheckpointPath: "checkpoint.json"},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Start: now, End: now.Add(-day)},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: "a"}, {ID: "a"}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: ".."}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: "."}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: "../a"}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: "a/b"}}},
+		{CheckpointPath: "checkpoint.json", Dir: "data", Sites: []BackfillSite{{ID: `a\b`}}},
+	}
+
+	for _, c := range configs {
+		if _, err := NewBackfill(api, c); err != ErrInvalidBackfill {
+			t.Errorf("Should have return ErrInvalidBackfill for %+v, got %v", c, err)
+		}
+	}
+}
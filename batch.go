@@ -0,0 +1,135 @@
+package darksky
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultBatchConcurrency = 4
+
+// BatchQuery is a single query of a batch: a forecast, or a time machine request when Time is set.
+type BatchQuery struct {
+	Lat     float64
+	Lng     float64
+	Time    time.Time
+	Options []Option
+}
+
+// BatchResult is the outcome of a BatchQuery.
+type BatchResult struct {
+	Query BatchQuery
+	Data  *APIData
+	Err   error
+}
+
+// BatchProgress is given to BatchConfig.OnProgress each time a query completes.
+type BatchProgress struct {
+	// Index of the completed query in the batch.
+	Index int
+	// Done is the number of queries completed so far, out of Total.
+	Done  int
+	Total int
+	// Result of the completed query.
+	Result BatchResult
+}
+
+// BatchConfig tunes how a batch is executed.
+type BatchConfig struct {
+	// Concurrency is the maximum number of queries in flight. Defaults to 4.
+	Concurrency int
+	// OnProgress, when set, is called after each query. Calls are never concurrent, and are all
+	// made before Batch returns. The queries keep running while it is called.
+	OnProgress func(BatchProgress)
+}
+
+// Batch executes the queries concurrently and returns their results in the same order. A failing
+// query does not stop the others, its error being reported in its result. Once ctx is done, the
+// queries not yet started fail with ctx.Err().
+func (api API) Batch(ctx context.Context, queries []BatchQuery, c BatchConfig) []BatchResult {
+	results := make([]BatchResult, len(queries))
+	concurrency := c.Concurrency
+
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	if concurrency > len(queries) {
+		concurrency = len(queries)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var next, done int
+
+	// Progress is reported from its own goroutine, so a slow callback does not hold back the
+	// workers. The channel holds every query, so sending never blocks.
+	progress := make(chan BatchProgress, len(queries))
+	reported := make(chan struct{})
+
+	go func() {
+		defer close(reported)
+
+		for p := range progress {
+			if c.OnProgress != nil {
+				c.OnProgress(p)
+			}
+		}
+	}()
+
+	// take returns the index of the next query to run, or false once all were started or ctx
+	// is done.
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if next == len(queries) || ctx.Err() != nil {
+			return 0, false
+		}
+
+		next++
+
+		return next - 1, true
+	}
+
+	complete := func(i int, r BatchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		results[i] = r
+		done++
+		progress <- BatchProgress{Index: i, Done: done, Total: len(queries), Result: r}
+	}
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i, ok := take(); ok; i, ok = take() {
+				q := queries[i]
+				r := BatchResult{Query: q}
+
+				if q.Time.IsZero() {
+					r.Data, r.Err = api.ForecastContext(ctx, q.Lat, q.Lng, q.Options...)
+				} else {
+					r.Data, r.Err = api.TimeMachineContext(ctx, q.Lat, q.Lng, q.Time, q.Options...)
+				}
+
+				complete(i, r)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i := next; i < len(queries); i++ {
+		complete(i, BatchResult{Query: queries[i], Err: ctx.Err()})
+	}
+
+	close(progress)
+	<-reported
+
+	return results
+}
@@ -0,0 +1,205 @@
+package darksky
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// HTTPClientConcurrencyMock records the maximum number of requests in flight at once.
+type HTTPClientConcurrencyMock struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	delay    time.Duration
+}
+
+func (c *HTTPClientConcurrencyMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.inFlight++
+
+	if c.inFlight > c.max {
+		c.max = c.inFlight
+	}
+
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(c.delay):
+		return ClientMock.Do(req)
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client := &HTTPClientConcurrencyMock{delay: 5 * time.Millisecond}
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queries []BatchQuery
+
+	for i := 0; i < 12; i++ {
+		q := BatchQuery{Lat: defaultLat + float64(i), Lng: defaultLng}
+
+		switch i % 3 {
+		case 1:
+			q.Time = time.Now()
+		case 2:
+			q.Options = []Option{LanguageOption("test")}
+		}
+
+		queries = append(queries, q)
+	}
+
+	var progress []BatchProgress
+
+	results := api.Batch(context.Background(), queries, BatchConfig{
+		Concurrency: 3,
+		OnProgress:  func(p BatchProgress) { progress = append(progress, p) },
+	})
+
+	assertInt(t, "results", int64(len(results)), 12)
+
+	for i, r := range results {
+		assertFloat(t, "Lat", r.Query.Lat, queries[i].Lat)
+
+		switch i % 3 {
+		case 0:
+			validateForecast(t, r.Data)
+		case 1:
+			validateTimeMachine(t, r.Data)
+		case 2:
+			if r.Err != ErrLanguageNotSupported || r.Data != nil {
+				t.Errorf("Query %d should have failed with ErrLanguageNotSupported, got %v", i, r.Err)
+			}
+		}
+	}
+
+	if client.max > 3 {
+		t.Errorf("At most 3 requests should have been in flight, got %d", client.max)
+	}
+
+	assertInt(t, "progress", int64(len(progress)), 12)
+
+	for i, p := range progress {
+		assertInt(t, "Done", int64(p.Done), int64(i+1))
+		assertInt(t, "Total", int64(p.Total), 12)
+
+		if p.Result.Query.Lat != queries[p.Index].Lat {
+			t.Errorf("Progress %d should report the result of query %d", i, p.Index)
+		}
+	}
+}
+
+func TestBatchSlowProgress(t *testing.T) {
+	const total = 6
+
+	var requests int32
+	allStarted := make(chan struct{})
+
+	client := HTTPClientFuncMock(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&requests, 1) == total {
+			close(allStarted)
+		}
+
+		return ClientMock.Do(req)
+	})
+
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := make([]BatchQuery, total)
+
+	for i := range queries {
+		queries[i] = BatchQuery{Lat: float64(i), Lng: defaultLng}
+	}
+
+	var inFlight, done int32
+
+	results := api.Batch(context.Background(), queries, BatchConfig{
+		Concurrency: 2,
+		OnProgress: func(p BatchProgress) {
+			if atomic.AddInt32(&inFlight, 1) > 1 {
+				t.Error("Progress calls should not be concurrent")
+			}
+
+			// The first call blocks until every query reached the client.
+			if p.Done == 1 {
+				select {
+				case <-allStarted:
+				case <-time.After(time.Second):
+					t.Error("Queries should have been started while the progress call was blocked")
+				}
+			}
+
+			if d := atomic.AddInt32(&done, 1); int32(p.Done) != d {
+				t.Errorf("Done should be %d, got %d", d, p.Done)
+			}
+
+			atomic.AddInt32(&inFlight, -1)
+		},
+	})
+
+	assertInt(t, "results", int64(len(results)), total)
+	assertInt(t, "progress", int64(done), total)
+}
+
+func TestBatchCancelled(t *testing.T) {
+	client := &HTTPClientConcurrencyMock{delay: time.Hour}
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := make([]BatchQuery, 10)
+
+	for i := range queries {
+		queries[i] = BatchQuery{Lat: float64(i), Lng: defaultLng}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results := api.Batch(ctx, queries, BatchConfig{Concurrency: 2})
+
+	for i, r := range results {
+		if r.Err != context.DeadlineExceeded {
+			t.Errorf("Query %d should have failed with context.DeadlineExceeded, got %v", i, r.Err)
+		}
+
+		assertFloat(t, "Lat", r.Query.Lat, float64(i))
+	}
+
+	if client.max != 2 {
+		t.Errorf("2 requests should have been in flight, got %d", client.max)
+	}
+}
+
+func TestBatchEmpty(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results := api.Batch(context.Background(), nil, BatchConfig{}); len(results) != 0 {
+		t.Errorf("Empty batch should return no result, got %d", len(results))
+	}
+}
@@ -0,0 +1,200 @@
+package darksky
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNilCache occurs when passing a nil cache to the CacheOption.
+	ErrNilCache = errors.New("cache provided cannot be nil")
+
+	// ErrCacheMiss is returned by a Cache when it holds no fresh entry for a key.
+	ErrCacheMiss = errors.New("no fresh entry in cache")
+)
+
+// Cache keeps successful responses so identical queries can be answered without calling the API.
+// The API consults it before sending a request. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the entry stored under key, or ErrCacheMiss when there is none or it expired.
+	Get(key string) (*CacheEntry, error)
+	// Set stores the entry under key until it expires.
+	Set(key string, entry *CacheEntry) error
+}
+
+// CacheEntry is a successful response kept by a cache.
+type CacheEntry struct {
+	// Content of the response, uncompressed.
+	Content []byte
+	// Header of the response.
+	Header http.Header
+	// Expires is when the entry stops being served.
+	Expires time.Time
+}
+
+// CacheStats are counters of the cache activity.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// MemoryCache keeps responses in memory, up to a maximum number of entries, evicting the least
+// recently used one when full. It is safe for concurrent use.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	stats      CacheStats
+	now        func() time.Time
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates a cache holding at most maxEntries responses, 0 meaning no limit. Entries
+// live for ttl, or when ttl is 0, for the lifetime given by the Cache-Control or Expires headers
+// of the response. Responses without such a lifetime are then not cached.
+func NewMemoryCache(maxEntries int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// CacheOption to serve identical queries from the cache while the response is fresh. Errors of
+// the cache are reported through the logger of the API, the query then going to the network.
+func CacheOption(c Cache) APIOption {
+	return func(api *API) error {
+		if c == nil {
+			return ErrNilCache
+		}
+
+		api.cache = c
+
+		return nil
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.ll.Len()
+
+	return stats
+}
+
+// Get returns the entry stored under key, if still fresh.
+func (c *MemoryCache) Get(key string) (*CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+
+	if !ok {
+		c.stats.Misses++
+		return nil, ErrCacheMiss
+	}
+
+	item := el.Value.(*memoryCacheItem)
+
+	if !c.now().Before(item.entry.Expires) {
+		c.remove(el)
+		c.stats.Misses++
+
+		return nil, ErrCacheMiss
+	}
+
+	c.ll.MoveToFront(el)
+	c.stats.Hits++
+
+	return item.entry, nil
+}
+
+// Set stores the entry under key, unless it is already expired.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) error {
+	entry, ok := withTTL(entry, c.ttl, c.now())
+
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		c.ll.MoveToFront(el)
+
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryCacheItem{key, entry})
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+		c.stats.Evictions++
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryCacheItem).key)
+}
+
+// cacheKey identifies a query regardless of the secret used: the endpoint, the coordinates as
+// formatted in the request path, which are rounded to 4 decimals, and the options with the
+// exclude list sorted.
+func cacheKey(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	coords := segments[len(segments)-1]
+	prefix := strings.Join(segments[:len(segments)-2], "/")
+
+	q := u.Query()
+
+	if ex := q.Get(excludeOptionKey); ex != "" {
+		blocks := strings.Split(strings.Trim(ex, "[]"), ",")
+		sort.Strings(blocks)
+		q.Set(excludeOptionKey, "["+strings.Join(blocks, ",")+"]")
+	}
+
+	return u.Host + prefix + "/" + coords + "?" + q.Encode()
+}
+
+// withTTL overrides the expiry of the entry when ttl is set, and tells whether it is still fresh.
+func withTTL(entry *CacheEntry, ttl time.Duration, now time.Time) (*CacheEntry, bool) {
+	if ttl > 0 {
+		e := *entry
+		e.Expires = now.Add(ttl)
+		entry = &e
+	}
+
+	return entry, now.Before(entry.Expires)
+}
+
+func newCacheEntry(content []byte, m *ResponseMetadata) *CacheEntry {
+	return &CacheEntry{
+		Content: content,
+		Header:  m.Header,
+		Expires: time.Now().Add(m.TTL()),
+	}
+}
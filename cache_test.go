@@ -0,0 +1,168 @@
+package darksky
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newCacheControlClient(cacheControl string) *HTTPClientCountingMock {
+	return &HTTPClientCountingMock{cacheControl: cacheControl}
+}
+
+func TestCacheKey(t *testing.T) {
+	keyOf := func(secret string, lat, lng float64, opts ...Option) string {
+		r, err := newForecastRequest(defaultBaseURL, secret, lat, lng, opts)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return cacheKey(r.URL)
+	}
+
+	key := keyOf("secret", 37.82671, -122.42334, ExcludeOption(ExMinutely, ExHourly), UnitOption(UnitSI))
+
+	assertString(t, "key", key, "api.darksky.net/forecast/37.8267,-122.4233?exclude=%5Bhourly%2Cminutely%5D&units=si")
+	assertString(t, "key", keyOf("other-secret", 37.8267, -122.4233, UnitOption(UnitSI), ExcludeOption(ExHourly, ExMinutely)), key)
+
+	if keyOf("secret", 37.8267, -122.4233) == key {
+		t.Error("Keys of queries with different options should differ")
+	}
+
+	base, err := parseBaseURL("http://127.0.0.1:8080/weather")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newTimeMachineRequest(base, "secret", 37.8267, -122.4233, time.Unix(255657600, 0), nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "key", cacheKey(r.URL), "127.0.0.1:8080/weather/forecast/37.8267,-122.4233,255657600?")
+}
+
+func TestCacheOption(t *testing.T) {
+	client := newCacheControlClient("max-age=600")
+	cache := NewMemoryCache(10, 0)
+	api, err := NewAPI("test-secret", HTTPClientOption(client), CacheOption(cache))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := api.Forecast(defaultLat+0.00001, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateForecast(t, second)
+	assertInt(t, "requests", int64(client.requests), 1)
+
+	if first.Metadata.Cached || !second.Metadata.Cached {
+		t.Error("Only the second response should come from the cache")
+	}
+
+	if first == second || &first.Hourly.Data[0] == &second.Hourly.Data[0] {
+		t.Error("Cached data should not be shared between callers")
+	}
+
+	stats := cache.Stats()
+
+	assertInt(t, "Hits", int64(stats.Hits), 1)
+	assertInt(t, "Misses", int64(stats.Misses), 1)
+	assertInt(t, "Entries", int64(stats.Entries), 1)
+}
+
+func TestCacheWithoutLifetime(t *testing.T) {
+	client := newCacheControlClient("")
+	api, err := NewAPI("test-secret", HTTPClientOption(client), CacheOption(NewMemoryCache(10, 0)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertInt(t, "requests", int64(client.requests), 2)
+}
+
+func TestCacheConfiguredTTL(t *testing.T) {
+	clock := &fakeClock{time.Now()}
+	cache := NewMemoryCache(10, time.Minute)
+	cache.now = clock.now
+
+	cache.Set("key", &CacheEntry{Content: []byte("{}")})
+
+	if _, err := cache.Get("key"); err != nil {
+		t.Error("Entry should be fresh with the configured TTL")
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+
+	if _, err := cache.Get("key"); err != ErrCacheMiss {
+		t.Error("Entry should have expired")
+	}
+
+	assertInt(t, "Entries", int64(cache.Stats().Entries), 0)
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	cache := NewMemoryCache(2, time.Hour)
+
+	cache.Set("a", &CacheEntry{})
+	cache.Set("b", &CacheEntry{})
+	cache.Get("a")
+	cache.Set("c", &CacheEntry{})
+
+	if _, err := cache.Get("b"); err != ErrCacheMiss {
+		t.Error("Least recently used entry should have been evicted")
+	}
+
+	for _, k := range []string{"a", "c"} {
+		if _, err := cache.Get(k); err != nil {
+			t.Errorf("Entry %s should still be cached", k)
+		}
+	}
+
+	stats := cache.Stats()
+
+	assertInt(t, "Evictions", int64(stats.Evictions), 1)
+	assertInt(t, "Entries", int64(stats.Entries), 2)
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	errClient := newErrorClient(http.StatusServiceUnavailable, "Service Unavailable", "text/plain")
+	cache := NewMemoryCache(10, time.Hour)
+	api, err := NewAPI("test-secret", HTTPClientOption(errClient), CacheOption(cache))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err == nil {
+		t.Error("Should have return an error")
+	}
+
+	assertInt(t, "Entries", int64(cache.Stats().Entries), 0)
+}
+
+func TestErrNilCache(t *testing.T) {
+	if _, err := NewAPI("secret", CacheOption(nil)); err != ErrNilCache {
+		t.Error("Nil cache should return ErrNilCache")
+	}
+}
@@ -0,0 +1,248 @@
+// Command darksky queries the Dark Sky API from the command line.
+//
+// Usage:
+//
+//	darksky forecast [flags] <lat> <lng>
+//	darksky timemachine [flags] <lat> <lng> <time>
+//
+// The secret is read from the DARKSKY_SECRET environment variable, and the endpoint can be
+// replaced by a compatible service with DARKSKY_BASE_URL. The time of a time machine query is
+// either RFC 3339, a date (YYYY-MM-DD) or a unix timestamp.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/averagegeek/darksky"
+)
+
+const (
+	secretEnv  = "DARKSKY_SECRET"
+	baseURLEnv = "DARKSKY_BASE_URL"
+
+	usage = `Usage:
+  darksky forecast [flags] <lat> <lng>
+  darksky timemachine [flags] <lat> <lng> <time>
+
+Environment:
+  DARKSKY_SECRET    secret of the API, required
+  DARKSKY_BASE_URL  base URL of a Dark Sky compatible service
+
+Flags:
+`
+)
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	os.Exit(run(ctx, os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// run executes the command, returning the exit code: 1 when the query fails, 2 on invalid usage.
+func run(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	err := execute(ctx, args, getenv, stdout, stderr)
+
+	switch err {
+	case nil, flag.ErrHelp:
+		return 0
+	case errUsage:
+		return 2
+	}
+
+	fmt.Fprintf(stderr, "darksky: %s\n", err)
+
+	return 1
+}
+
+func execute(ctx context.Context, args []string, getenv func(string) string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("darksky", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	lang := fs.String("lang", "", "language of the summaries, like fr or zh-tw")
+	units := fs.String("units", "", "units of the values: auto, ca, si, uk2 or us")
+	exclude := fs.String("exclude", "", "comma separated blocks to exclude: currently, minutely, hourly, daily, alerts, flags")
+	extend := fs.Bool("extend", false, "return hourly data for the next 168 hours instead of 48")
+	format := fs.String("format", formatTable, "output format: json, table or csv")
+
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	cmd := args[0]
+
+	if cmd == "-h" || cmd == "-help" || cmd == "--help" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	flags, rest := splitArgs(args[1:])
+
+	if err := fs.Parse(flags); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+
+		// The flag set already reported the error along with the usage.
+		return errUsage
+	}
+
+	w, err := newWriter(*format)
+
+	if err != nil {
+		return err
+	}
+
+	var opts []darksky.Option
+
+	if *lang != "" {
+		opts = append(opts, darksky.LanguageOption(*lang))
+	}
+
+	if *units != "" {
+		opts = append(opts, darksky.UnitOption(*units))
+	}
+
+	if *exclude != "" {
+		opts = append(opts, darksky.ExcludeOption(strings.Split(*exclude, ",")...))
+	}
+
+	if *extend {
+		opts = append(opts, darksky.ExtendOption())
+	}
+
+	var params int
+
+	switch cmd {
+	case "forecast":
+		params = 2
+	case "timemachine":
+		params = 3
+	default:
+		fs.Usage()
+		return errUsage
+	}
+
+	positionals := append(fs.Args(), rest...)
+
+	if len(positionals) != params {
+		fs.Usage()
+		return errUsage
+	}
+
+	lat, lng, err := parseCoordinates(positionals[0], positionals[1])
+
+	if err != nil {
+		return err
+	}
+
+	api, err := newAPI(getenv)
+
+	if err != nil {
+		return err
+	}
+
+	var data *darksky.APIData
+
+	if cmd == "forecast" {
+		data, err = api.ForecastContext(ctx, lat, lng, opts...)
+	} else {
+		var t time.Time
+
+		if t, err = parseTime(positionals[2]); err != nil {
+			return err
+		}
+
+		data, err = api.TimeMachineContext(ctx, lat, lng, t, opts...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return w(stdout, data)
+}
+
+// splitArgs splits the arguments before the first number, so a negative latitude like -33.86 is
+// not taken for a flag.
+func splitArgs(args []string) (flags, rest []string) {
+	for i, a := range args {
+		if _, err := strconv.ParseFloat(a, 64); err == nil {
+			return args[:i], args[i:]
+		}
+	}
+
+	return args, nil
+}
+
+func newAPI(getenv func(string) string) (*darksky.API, error) {
+	secret := getenv(secretEnv)
+
+	if secret == "" {
+		return nil, fmt.Errorf("%s environment variable is not set", secretEnv)
+	}
+
+	var opts []darksky.APIOption
+
+	if base := getenv(baseURLEnv); base != "" {
+		opts = append(opts, darksky.BaseURLOption(base))
+	}
+
+	return darksky.NewAPI(secret, opts...)
+}
+
+func parseCoordinates(lat, lng string) (float64, float64, error) {
+	la, err := strconv.ParseFloat(lat, 64)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q", lat)
+	}
+
+	ln, err := strconv.ParseFloat(lng, 64)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q", lng)
+	}
+
+	return la, ln, nil
+}
+
+// parseTime reads RFC 3339 times, dates at midnight UTC, or unix timestamps.
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+
+	if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, YYYY-MM-DD or a unix timestamp", v)
+}
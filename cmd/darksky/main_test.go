@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/averagegeek/darksky"
+	"github.com/averagegeek/darksky/darkskytest"
+)
+
+func runCommand(t *testing.T, s *darkskytest.Server, args ...string) (int, string, string) {
+	env := map[string]string{
+		secretEnv:  "secret",
+		baseURLEnv: s.URL,
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.Background(), args, func(k string) string { return env[k] }, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestForecastJSON(t *testing.T) {
+	s := darkskytest.NewServer("secret", darkskytest.GzipOption())
+	defer s.Close()
+
+	code, out, errOut := runCommand(t, s, "forecast", "-format", "json", "-units", "si", "-lang", "fr", "-exclude", "minutely,alerts", "-extend", "42.3601", "-71.0589")
+
+	if code != 0 {
+		t.Fatalf("Exit code should be 0, got %d: %s", code, errOut)
+	}
+
+	var data darksky.APIData
+
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Latitude != 42.3601 || data.Flags.Units != "si" {
+		t.Errorf("Output should be the forecast in si units, got %+v", data)
+	}
+
+	q := s.Requests()[0].Query.Values
+
+	if q.Get("lang") != "fr" || q.Get("exclude") != "[minutely,alerts]" || q.Get("extend") != "hourly" {
+		t.Errorf("Flags should map to options, got %v", q)
+	}
+}
+
+func TestForecastNegativeLatitude(t *testing.T) {
+	s := darkskytest.NewServer("secret")
+	defer s.Close()
+
+	for _, args := range [][]string{
+		{"forecast", "-33.86", "151.2"},
+		{"forecast", "-format", "json", "-33.86", "-151.2"},
+	} {
+		code, _, errOut := runCommand(t, s, args...)
+
+		if code != 0 {
+			t.Errorf("%v should exit with code 0, got %d: %s", args, code, errOut)
+		}
+	}
+
+	if q := s.Requests()[0].Query; q.Lat != -33.86 || q.Lng != 151.2 {
+		t.Errorf("Expected coordinates -33.86,151.2, got %v,%v", q.Lat, q.Lng)
+	}
+}
+
+func TestTimeMachineCSV(t *testing.T) {
+	s := darkskytest.NewServer("secret")
+	defer s.Close()
+
+	code, out, errOut := runCommand(t, s, "timemachine", "-format", "csv", "42.3601", "-71.0589", "2019-03-01T15:00:00Z")
+
+	if code != 0 {
+		t.Fatalf("Exit code should be 0, got %d: %s", code, errOut)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1+1+49+8 {
+		t.Fatalf("CSV should have a header, the currently, 49 hourly and 8 daily rows, got %d", len(records))
+	}
+
+	if records[1][0] != "currently" || records[1][1] != "2019-03-01T15:00:00Z" {
+		t.Errorf("First row should be currently at the requested time, got %v", records[1])
+	}
+
+	if records[len(records)-1][0] != "daily" {
+		t.Errorf("Last row should be daily, got %v", records[len(records)-1])
+	}
+}
+
+func TestForecastTable(t *testing.T) {
+	s := darkskytest.NewServer("secret")
+	defer s.Close()
+
+	s.Enqueue(darkskytest.Response{Data: &darksky.APIData{
+		Latitude:  42.3601,
+		Longitude: -71.0589,
+		Timezone:  "America/New_York",
+		Currently: darksky.DataPoint{Time: 1551452400, Summary: "Light Snow", Temperature: 28.4},
+		Daily:     darksky.DataBlock{Data: []darksky.DataPoint{{Time: 1551416400, Summary: "Snow in the evening.", TemperatureHigh: 31.2, TemperatureLow: 20.5}}},
+		Flags:     darksky.Flags{Units: "us"},
+		Alerts: []darksky.Alert{
+			{Title: "Winter Storm Warning", Severity: "warning", Expires: 1551484800},
+			{Title: "Frost Advisory", Severity: "advisory"},
+		},
+	}})
+
+	code, out, errOut := runCommand(t, s, "forecast", "42.3601", "-71.0589")
+
+	if code != 0 {
+		t.Fatalf("Exit code should be 0, got %d: %s", code, errOut)
+	}
+
+	for _, expected := range []string{
+		"America/New_York",
+		"2019-03-01 10:00 EST  Light Snow",
+		"28.4",
+		"Snow in the evening.",
+		"31.2  20.5",
+		"Winter Storm Warning (warning) until 2019-03-01 19:00 EST",
+		"Frost Advisory (advisory)\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Table should contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "0001-01-01") {
+		t.Errorf("Alerts without expiry should not have an end, got:\n%s", out)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	s := darkskytest.NewServer("secret")
+	defer s.Close()
+
+	invalid := [][]string{
+		{},
+		{"history", "42.3601", "-71.0589"},
+		{"forecast", "42.3601"},
+		{"timemachine", "42.3601", "-71.0589"},
+		{"forecast", "-unknown", "42.3601", "-71.0589"},
+	}
+
+	for _, args := range invalid {
+		if code, _, _ := runCommand(t, s, args...); code != 2 {
+			t.Errorf("%v should exit with code 2, got %d", args, code)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	s := darkskytest.NewServer("secret")
+	defer s.Close()
+
+	invalid := map[string][]string{
+		"unknown format": {"forecast", "-format", "xml", "42.3601", "-71.0589"},
+		"invalid time":   {"timemachine", "42.3601", "-71.0589", "yesterday"},
+		"invalid lat":    {"forecast", "north", "-71.0589"},
+		"not supported":  {"forecast", "-units", "metric", "42.3601", "-71.0589"},
+		"HTTP 400":       {"forecast", "142.3601", "-71.0589"},
+	}
+
+	for expected, args := range invalid {
+		code, _, errOut := runCommand(t, s, args...)
+
+		if code != 1 || !strings.Contains(errOut, expected) {
+			t.Errorf("%v should exit with code 1 and report %q, got %d: %s", args, expected, code, errOut)
+		}
+	}
+}
+
+func TestMissingSecret(t *testing.T) {
+	var stderr bytes.Buffer
+
+	code := run(context.Background(), []string{"forecast", "42.3601", "-71.0589"}, func(string) string { return "" }, &bytes.Buffer{}, &stderr)
+
+	if code != 1 || !strings.Contains(stderr.String(), secretEnv) {
+		t.Errorf("Missing secret should be reported, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	times := map[string]int64{
+		"2019-03-01T10:00:00-05:00": 1551452400,
+		"2019-03-01":                1551398400,
+		"1551452400":                1551452400,
+	}
+
+	for v, expected := range times {
+		ts, err := parseTime(v)
+
+		if err != nil || ts.Unix() != expected {
+			t.Errorf("%s should be parsed as %d, got %d (%v)", v, expected, ts.Unix(), err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/averagegeek/darksky"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv"
+
+	tableTimeLayout = "2006-01-02 15:04 MST"
+)
+
+type writer func(io.Writer, *darksky.APIData) error
+
+func newWriter(format string) (writer, error) {
+	switch format {
+	case formatJSON:
+		return writeJSON, nil
+	case formatTable:
+		return writeTable, nil
+	case formatCSV:
+		return writeCSV, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q, expected json, table or csv", format)
+}
+
+func writeJSON(w io.Writer, data *darksky.APIData) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(data)
+}
+
+// row is a data point of a block, as displayed in the table and CSV outputs.
+type row struct {
+	block string
+	point darksky.DataPoint
+}
+
+func rows(data *darksky.APIData) []row {
+	var rs []row
+
+	if data.Currently.Time != 0 {
+		rs = append(rs, row{"currently", data.Currently})
+	}
+
+	for _, p := range data.Hourly.Data {
+		rs = append(rs, row{"hourly", p})
+	}
+
+	for _, p := range data.Daily.Data {
+		rs = append(rs, row{"daily", p})
+	}
+
+	return rs
+}
+
+func writeTable(w io.Writer, data *darksky.APIData) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Location:\t%.4f, %.4f (%s)\n", data.Latitude, data.Longitude, data.Timezone)
+	fmt.Fprintf(tw, "Units:\t%s\n", data.Flags.Units)
+
+	block := ""
+
+	for _, r := range rows(data) {
+		if r.block != block {
+			block = r.block
+			fmt.Fprintf(tw, "\n%s\n", block)
+			fmt.Fprintln(tw, "TIME\tSUMMARY\tTEMP\tHIGH\tLOW\tPRECIP\tWIND\tHUMIDITY")
+		}
+
+		p := r.point
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.0f%%\t%.1f\t%.0f%%\n",
+			p.LocalTime().Format(tableTimeLayout),
+			p.Summary,
+			temperature(r.block != "daily", p.Temperature),
+			temperature(r.block == "daily", p.TemperatureHigh),
+			temperature(r.block == "daily", p.TemperatureLow),
+			p.PrecipProbability*100,
+			p.WindSpeed,
+			p.Humidity*100,
+		)
+	}
+
+	for _, a := range data.Alerts {
+		fmt.Fprintf(tw, "\nALERT\t%s (%s)", a.Title, a.Severity)
+
+		if a.Expires != 0 {
+			fmt.Fprintf(tw, " until %s", a.LocalExpires().Format(tableTimeLayout))
+		}
+
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// temperature is blank in the columns not applying to the block.
+func temperature(applies bool, v float64) string {
+	if !applies {
+		return "-"
+	}
+
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+var csvHeader = []string{
+	"block", "time", "summary", "icon", "temperature", "apparent_temperature", "temperature_high",
+	"temperature_low", "precip_probability", "precip_intensity", "precip_type", "humidity", "pressure",
+	"wind_speed", "wind_gust", "wind_bearing", "cloud_cover", "uv_index", "visibility",
+}
+
+func writeCSV(w io.Writer, data *darksky.APIData) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	for _, r := range rows(data) {
+		p := r.point
+
+		record := []string{
+			r.block,
+			p.LocalTime().Format(time.RFC3339),
+			p.Summary,
+			string(p.Icon),
+			f(p.Temperature),
+			f(p.ApparentTemperature),
+			f(p.TemperatureHigh),
+			f(p.TemperatureLow),
+			f(p.PrecipProbability),
+			f(p.PrecipIntensity),
+			string(p.PrecipType),
+			f(p.Humidity),
+			f(p.Pressure),
+			f(p.WindSpeed),
+			f(p.WindGust),
+			f(p.WindBearing),
+			f(p.CloudCover),
+			strconv.FormatInt(p.UvIndex, 10),
+			f(p.Visibility),
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
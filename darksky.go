@@ -3,15 +3,15 @@ package darksky
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -141,12 +141,19 @@ type APIData struct {
 	Latitude  float64   `json:"latitude"`
 	Longitude float64   `json:"longitude"`
 	Timezone  string    `json:"timezone"`
+	Offset    float64   `json:"offset"`
 	Currently DataPoint `json:"currently,omitempty"`
 	Minutely  DataBlock `json:"minutely,omitempty"`
 	Hourly    DataBlock `json:"hourly,omitempty"`
 	Daily     DataBlock `json:"daily,omitempty"`
-	Alerts    []Alert   `json:"alerts,omitempty"`
+	Alerts    Alerts    `json:"alerts,omitempty"`
 	Flags     Flags     `json:"flags,omitempty"`
+
+	// Metadata of the HTTP response the data comes from, nil when not obtained from a query.
+	Metadata *ResponseMetadata `json:"-"`
+
+	// Extras holds the properties unknown to this package.
+	Extras Extras `json:"-"`
 }
 
 // Alert If present, contains any severe weather alerts pertinent to the requested location.
@@ -154,74 +161,101 @@ type Alert struct {
 	Description string   `json:"description"`
 	Expires     int64    `json:"expires"`
 	Regions     []string `json:"regions"`
-	Severity    string   `json:"severity"`
+	Severity    Severity `json:"severity"`
 	Time        int64    `json:"time"`
 	Title       string   `json:"title"`
 	URI         string   `json:"uri"`
+
+	// Extras holds the properties unknown to this package.
+	Extras Extras `json:"-"`
+
+	loc *time.Location
 }
 
 // DataBlock object representation from the API.
 type DataBlock struct {
 	Data    []DataPoint `json:"data"`
 	Summary string      `json:"summary,omitempty"`
-	Icon    string      `json:"icon,omitempty"`
+	Icon    Icon        `json:"icon,omitempty"`
+
+	// Extras holds the properties unknown to this package.
+	Extras Extras `json:"-"`
+
+	loc *time.Location
 }
 
 // DataPoint object contains various properties, each representing the average
 // (unless otherwise specified) of a particular weather phenomenon occurring during
 // a period of time: an instant in the case of currently, a minute for minutely,
 // an hour for hourly, and a day for daily.
+//
+// Properties not returned by the API are left to their zero value, use Has to tell them
+// apart from those returned with a zero value.
 type DataPoint struct {
-	ApparentTemperature         float64 `json:"apparentTemperature,omitempty"`
-	ApparentTemperatureHigh     float64 `json:"apparentTemperatureHigh,omitempty"`
-	ApparentTemperatureHighTime int64   `json:"apparentTemperatureHighTime,omitempty"`
-	ApparentTemperatureLow      float64 `json:"apparentTemperatureLow,omitempty"`
-	ApparentTemperatureLowTime  int64   `json:"apparentTemperatureLowTime,omitempty"`
-	CloudCover                  float64 `json:"cloudCover,omitempty"`
-	DewPoint                    float64 `json:"dewPoint,omitempty"`
-	Humidity                    float64 `json:"humidity,omitempty"`
-	Icon                        string  `json:"icon,omitempty"`
-	MoonPhase                   float64 `json:"moonPhase,omitempty"`
-	NearestStormBearing         int64   `json:"nearestStormBearing,omitempty"`
-	NearestStormDistance        int64   `json:"nearestStormDistance,omitempty"`
-	Ozone                       float64 `json:"ozone,omitempty"`
-	PrecipAccumulation          float64 `json:"precipAccumulation,omitempty"`
-	PrecipIntensity             float64 `json:"precipIntensity,omitempty"`
-	PrecipIntensityError        float64 `json:"precipIntensityError,omitempty"`
-	PrecipIntensityMax          float64 `json:"precipIntensityMax,omitempty"`
-	PrecipIntensityMaxTime      int64   `json:"precipIntensityMaxTime,omitempty"`
-	PrecipProbability           float64 `json:"precipProbability,omitempty"`
-	PrecipType                  string  `json:"precipType,omitempty"`
-	Pressure                    float64 `json:"pressure,omitempty"`
-	Summary                     string  `json:"summary,omitempty"`
-	SunriseTime                 int64   `json:"sunriseTime,omitempty"`
-	SunsetTime                  int64   `json:"sunsetTime,omitempty"`
-	Temperature                 float64 `json:"temperature,omitempty"`
-	TemperatureHigh             float64 `json:"temperatureHigh,omitempty"`
-	TemperatureHighTime         int64   `json:"temperatureHighTime,omitempty"`
-	TemperatureLow              float64 `json:"temperatureLow,omitempty"`
-	TemperatureLowTime          int64   `json:"temperatureLowTime,omitempty"`
-	Time                        int64   `json:"time"`
-	UvIndex                     int64   `json:"uvIndex,omitempty"`
-	UvIndexTime                 int64   `json:"uvIndexTime,omitempty"`
-	Visibility                  float64 `json:"visibility,omitempty"`
-	WindBearing                 float64 `json:"windBearing,omitempty"`
-	WindGust                    float64 `json:"windGust,omitempty"`
-	WindGustTime                int64   `json:"windGustTime,omitempty"`
-	WindSpeed                   float64 `json:"windSpeed,omitempty"`
+	ApparentTemperature         float64    `json:"apparentTemperature,omitempty"`
+	ApparentTemperatureHigh     float64    `json:"apparentTemperatureHigh,omitempty"`
+	ApparentTemperatureHighTime int64      `json:"apparentTemperatureHighTime,omitempty"`
+	ApparentTemperatureLow      float64    `json:"apparentTemperatureLow,omitempty"`
+	ApparentTemperatureLowTime  int64      `json:"apparentTemperatureLowTime,omitempty"`
+	ApparentTemperatureMax      float64    `json:"apparentTemperatureMax,omitempty"`
+	ApparentTemperatureMaxTime  int64      `json:"apparentTemperatureMaxTime,omitempty"`
+	ApparentTemperatureMin      float64    `json:"apparentTemperatureMin,omitempty"`
+	ApparentTemperatureMinTime  int64      `json:"apparentTemperatureMinTime,omitempty"`
+	CloudCover                  float64    `json:"cloudCover,omitempty"`
+	DewPoint                    float64    `json:"dewPoint,omitempty"`
+	Humidity                    float64    `json:"humidity,omitempty"`
+	Icon                        Icon       `json:"icon,omitempty"`
+	MoonPhase                   float64    `json:"moonPhase,omitempty"`
+	NearestStormBearing         int64      `json:"nearestStormBearing,omitempty"`
+	NearestStormDistance        int64      `json:"nearestStormDistance,omitempty"`
+	Ozone                       float64    `json:"ozone,omitempty"`
+	PrecipAccumulation          float64    `json:"precipAccumulation,omitempty"`
+	PrecipIntensity             float64    `json:"precipIntensity,omitempty"`
+	PrecipIntensityError        float64    `json:"precipIntensityError,omitempty"`
+	PrecipIntensityMax          float64    `json:"precipIntensityMax,omitempty"`
+	PrecipIntensityMaxTime      int64      `json:"precipIntensityMaxTime,omitempty"`
+	PrecipProbability           float64    `json:"precipProbability,omitempty"`
+	PrecipType                  PrecipType `json:"precipType,omitempty"`
+	Pressure                    float64    `json:"pressure,omitempty"`
+	Summary                     string     `json:"summary,omitempty"`
+	SunriseTime                 int64      `json:"sunriseTime,omitempty"`
+	SunsetTime                  int64      `json:"sunsetTime,omitempty"`
+	Temperature                 float64    `json:"temperature,omitempty"`
+	TemperatureHigh             float64    `json:"temperatureHigh,omitempty"`
+	TemperatureHighTime         int64      `json:"temperatureHighTime,omitempty"`
+	TemperatureLow              float64    `json:"temperatureLow,omitempty"`
+	TemperatureLowTime          int64      `json:"temperatureLowTime,omitempty"`
+	TemperatureMax              float64    `json:"temperatureMax,omitempty"`
+	TemperatureMaxTime          int64      `json:"temperatureMaxTime,omitempty"`
+	TemperatureMin              float64    `json:"temperatureMin,omitempty"`
+	TemperatureMinTime          int64      `json:"temperatureMinTime,omitempty"`
+	Time                        int64      `json:"time"`
+	UvIndex                     int64      `json:"uvIndex,omitempty"`
+	UvIndexTime                 int64      `json:"uvIndexTime,omitempty"`
+	Visibility                  float64    `json:"visibility,omitempty"`
+	WindBearing                 float64    `json:"windBearing,omitempty"`
+	WindGust                    float64    `json:"windGust,omitempty"`
+	WindGustTime                int64      `json:"windGustTime,omitempty"`
+	WindSpeed                   float64    `json:"windSpeed,omitempty"`
+
+	// Extras holds the properties unknown to this package.
+	Extras Extras `json:"-"`
+
+	loc     *time.Location
+	units   string
+	present uint64
 }
 
 // Flags object contains miscellaneous metadata about the request.
 type Flags struct {
 	DarkskyUnavailable string   `json:"darksky-unavailable,omitempty"`
+	MeteoalarmLicense  string   `json:"meteoalarm-license,omitempty"`
 	Sources            []string `json:"sources"`
 	NearestStation     float64  `json:"nearest-station"`
 	Units              string   `json:"units"`
-}
 
-type apiError struct {
-	Code int    `json:"code"`
-	Err  string `json:"error"`
+	// Extras holds the properties unknown to this package.
+	Extras Extras `json:"-"`
 }
 
 // HTTPClient let's you substitute the default http.Client for a custom one.
@@ -231,9 +265,15 @@ type HTTPClient interface {
 
 // API is used to make requests.
 type API struct {
-	secret string
-	client HTTPClient
-	logger *log.Logger
+	secret  string
+	client  HTTPClient
+	logger  *log.Logger
+	baseURL *url.URL
+	retry   *RetryPolicy
+	quota   *Quota
+	cache   Cache
+	flights *flightGroup
+	sleep   func(context.Context, time.Duration) error
 }
 
 // APIOption to override defaults of the api, like the HTTP client.
@@ -276,13 +316,30 @@ func LoggerOption(l *log.Logger) APIOption {
 	}
 }
 
+// BaseURLOption to query a Dark Sky compatible service, or a local stand-in, instead of the
+// official API. The URL is made of a scheme, a host and an optional path prefix, for example
+// "https://api.pirateweather.net" or "http://127.0.0.1:8080/weather".
+func BaseURLOption(rawurl string) APIOption {
+	return func(api *API) error {
+		u, err := parseBaseURL(rawurl)
+
+		if err != nil {
+			return err
+		}
+
+		api.baseURL = u
+
+		return nil
+	}
+}
+
 // NewAPI is a helper function to create a new API.
 func NewAPI(secret string, opts ...APIOption) (*API, error) {
 	if secret == "" {
 		return nil, ErrEmptySecret
 	}
 
-	api := &API{secret: secret}
+	api := &API{secret: secret, sleep: sleepContext, flights: newFlightGroup()}
 
 	for _, opt := range opts {
 		if err := opt(api); err != nil {
@@ -298,51 +355,187 @@ func NewAPI(secret string, opts ...APIOption) (*API, error) {
 		api.logger = log.New(os.Stderr, "Darksky API Client - ", log.LstdFlags)
 	}
 
+	if api.baseURL == nil {
+		api.baseURL = defaultBaseURL
+	}
+
+	if api.quota != nil {
+		api.quota.setDefaultLogger(api.logger)
+	}
+
 	return api, nil
 }
 
 // Forecast query to the API.
 func (api API) Forecast(lat, lng float64, opts ...Option) (wd *APIData, err error) {
-	r, err := newForecastRequest(api.secret, lat, lng, opts)
+	return api.ForecastContext(context.Background(), lat, lng, opts...)
+}
+
+// ForecastContext query to the API, the request is bound to ctx so it can be cancelled or
+// given a deadline. When ctx is done before a response is received, ctx.Err() is returned.
+func (api API) ForecastContext(ctx context.Context, lat, lng float64, opts ...Option) (*APIData, error) {
+	r, err := newForecastRequest(api.baseURL, api.secret, lat, lng, opts)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return api.handleRequest(r)
+	return api.handleRequest(ctx, r)
 }
 
 // TimeMachine query to the API.
 func (api API) TimeMachine(lat, lng float64, time time.Time, opts ...Option) (*APIData, error) {
-	r, err := newTimeMachineRequest(api.secret, lat, lng, time, opts)
+	return api.TimeMachineContext(context.Background(), lat, lng, time, opts...)
+}
+
+// TimeMachineContext query to the API, the request is bound to ctx so it can be cancelled or
+// given a deadline. When ctx is done before a response is received, ctx.Err() is returned.
+func (api API) TimeMachineContext(ctx context.Context, lat, lng float64, time time.Time, opts ...Option) (*APIData, error) {
+	r, err := newTimeMachineRequest(api.baseURL, api.secret, lat, lng, time, opts)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return api.handleRequest(r)
+	return api.handleRequest(ctx, r)
 }
 
-func (api *API) handleRequest(r *http.Request) (*APIData, error) {
-	resp, err := api.client.Do(r)
+func (api *API) handleRequest(ctx context.Context, r *http.Request) (*APIData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var key string
+
+	if api.cache != nil {
+		key = cacheKey(r.URL)
+
+		entry, err := api.cache.Get(key)
+
+		if err == nil {
+			return unmarshalCacheEntry(entry)
+		}
+
+		if err != ErrCacheMiss {
+			api.logger.Println(err)
+		}
+	}
+
+	fetch := func(ctx context.Context) (*flightResponse, error) {
+		return api.fetch(ctx, r, key)
+	}
+
+	var fr *flightResponse
+	var err error
+
+	if api.flights == nil {
+		fr, err = fetch(ctx)
+	} else {
+		fr, err = api.flights.do(ctx, r.URL.String(), fetch)
+	}
+
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	// The flight response is shared with concurrent callers, each one gets its own copy.
+	resp := &http.Response{
+		StatusCode: fr.statusCode,
+		Header:     fr.header.Clone(),
+	}
+
+	data, err := unmarshalContent(resp, fr.content)
 
 	if err != nil {
 		return nil, err
 	}
 
+	data.Metadata = newResponseMetadata(resp)
+
+	return data, err
+}
+
+// fetch sends the request and reads the response, storing it in the cache under key when
+// successful.
+func (api *API) fetch(ctx context.Context, r *http.Request, key string) (*flightResponse, error) {
+	resp, err := api.do(ctx, r.WithContext(ctx))
+
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
 	content, err := extractContent(resp, api.logger)
 
 	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	if api.cache != nil && resp.StatusCode == http.StatusOK {
+		if err := api.cache.Set(key, newCacheEntry(content, newResponseMetadata(resp))); err != nil {
+			api.logger.Println(err)
+		}
+	}
+
+	return &flightResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		content:    content,
+	}, nil
+}
+
+// unmarshalCacheEntry decodes a fresh copy of the cached data, so callers never share it.
+func unmarshalCacheEntry(entry *CacheEntry) (*APIData, error) {
+	var data APIData
+
+	if err := json.Unmarshal(entry.Content, &data); err != nil {
 		return nil, err
 	}
 
-	data, err := unmarshalContent(resp, content)
+	data.Metadata = newResponseMetadata(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     entry.Header.Clone(),
+	})
+	data.Metadata.Cached = true
 
-	if err != nil {
+	return &data, nil
+}
+
+// do sends the request through the client, retrying it when a retry policy is set.
+func (api *API) do(ctx context.Context, r *http.Request) (*http.Response, error) {
+	if api.retry == nil {
+		return api.send(r)
+	}
+
+	return api.doWithRetry(ctx, r)
+}
+
+// send makes a single attempt, accounting for it in the quota if any.
+func (api *API) send(r *http.Request) (*http.Response, error) {
+	if api.quota == nil {
+		return api.client.Do(r)
+	}
+
+	if err := api.quota.reserve(); err != nil {
 		return nil, err
 	}
 
-	return data, err
+	resp, err := api.client.Do(r)
+
+	if err == nil {
+		api.quota.observe(newResponseMetadata(resp))
+	}
+
+	return resp, err
+}
+
+// contextError gives precedence to the context error when the context is done, since the
+// error returned by the client is then only a consequence of the cancellation.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
 }
 
 func extractContent(resp *http.Response, logger *log.Logger) ([]byte, error) {
@@ -352,7 +545,7 @@ func extractContent(resp *http.Response, logger *log.Logger) ([]byte, error) {
 		return nil, err
 	}
 
-	defer close(resp.Body, logger)
+	defer closeLogged(resp.Body, logger)
 
 	switch resp.Header.Get("Content-Encoding") {
 	case "gzip":
@@ -364,33 +557,16 @@ func extractContent(resp *http.Response, logger *log.Logger) ([]byte, error) {
 
 func unmarshalContent(resp *http.Response, content []byte) (*APIData, error) {
 	if resp.StatusCode >= 400 {
-		contentType := resp.Header.Get("Content-Type")
-
-		if contentType == "text/plain" {
-			return nil, HTTPError(resp.StatusCode, string(content))
-		} else if strings.Contains(contentType, "application/json") {
-			var data apiError
-
-			if err := json.Unmarshal(content, &data); err != nil {
-				return nil, err
-			}
-
-			return nil, HTTPError(resp.StatusCode, data.Err)
-		}
+		return nil, newAPIError(resp, content)
 	}
 
-	var data *APIData
+	var data APIData
 
 	if err := json.Unmarshal(content, &data); err != nil {
 		return nil, err
 	}
 
-	return data, nil
-}
-
-// HTTPError formats a txt error to inform it's an HTTP error and also include code.
-func HTTPError(code int, txt string) error {
-	return fmt.Errorf("HTTP %d Error - %s", code, txt)
+	return &data, nil
 }
 
 func uncompressGzip(body []byte, logger *log.Logger) ([]byte, error) {
@@ -401,7 +577,7 @@ func uncompressGzip(body []byte, logger *log.Logger) ([]byte, error) {
 		return nil, err
 	}
 
-	defer close(gr, logger)
+	defer closeLogged(gr, logger)
 
 	b, err := ioutil.ReadAll(gr)
 
@@ -412,7 +588,7 @@ func uncompressGzip(body []byte, logger *log.Logger) ([]byte, error) {
 	return b, err
 }
 
-func close(c io.Closer, l *log.Logger) {
+func closeLogged(c io.Closer, l *log.Logger) {
 	err := c.Close()
 
 	if err != nil {
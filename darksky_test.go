@@ -3,11 +3,16 @@ package darksky
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -65,6 +70,23 @@ func (unc *HTTPClientErrorMock) Do(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
+// HTTPClientFuncMock adapts a function to the HTTPClient interface.
+type HTTPClientFuncMock func(*http.Request) (*http.Response, error)
+
+func (f HTTPClientFuncMock) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// HTTPClientBlockingMock never answers, it waits for the request context to be done like
+// http.Client would do on a hanging connection.
+type HTTPClientBlockingMock struct{}
+
+func (c HTTPClientBlockingMock) Do(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+
+	return nil, fmt.Errorf("Get %s: %w", req.URL, req.Context().Err())
+}
+
 func newErrorClient(code int, body, contentType string) *HTTPClientErrorMock {
 	return &HTTPClientErrorMock{code, body, contentType}
 }
@@ -178,6 +200,87 @@ func TestGetTimeMachineWithInvalidOption(t *testing.T) {
 	}
 }
 
+func TestGetForecastContext(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	d, err := api.ForecastContext(context.Background(), defaultLat, defaultLng)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	validateForecast(t, d)
+}
+
+func TestGetTimeMachineContext(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	d, err := api.TimeMachineContext(context.Background(), defaultLat, defaultLng, time.Now())
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	validateTimeMachine(t, d)
+}
+
+func TestContextAlreadyCancelled(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err = api.ForecastContext(ctx, defaultLat, defaultLng); err != context.Canceled {
+		t.Errorf("Should have return context.Canceled, got %v", err)
+	}
+
+	if _, err = api.TimeMachineContext(ctx, defaultLat, defaultLng, time.Now()); err != context.Canceled {
+		t.Errorf("Should have return context.Canceled, got %v", err)
+	}
+}
+
+func TestContextDeadlineExceeded(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(HTTPClientBlockingMock{}))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err = api.ForecastContext(ctx, defaultLat, defaultLng); err != context.DeadlineExceeded {
+		t.Errorf("Should have return context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestContextCancelledInFlight(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(HTTPClientBlockingMock{}))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if _, err = api.TimeMachineContext(ctx, defaultLat, defaultLng, time.Now()); err != context.Canceled {
+		t.Errorf("Should have return context.Canceled, got %v", err)
+	}
+}
+
 func TestRequestWithoutGzipEncoding(t *testing.T) {
 	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
 
@@ -185,14 +288,14 @@ func TestRequestWithoutGzipEncoding(t *testing.T) {
 		t.Error(err)
 	}
 
-	r, err := newForecastRequest(api.secret, defaultLat, defaultLng, []Option{})
+	r, err := newForecastRequest(api.baseURL, api.secret, defaultLat, defaultLng, []Option{})
 
 	if err != nil {
 		t.Error(err)
 	}
 
 	r.Header.Del("Accept-Encoding")
-	d, err := api.handleRequest(r)
+	d, err := api.handleRequest(context.Background(), r)
 
 	if err != nil {
 		t.Error(err)
@@ -225,6 +328,42 @@ func TestErrNilLogger(t *testing.T) {
 	}
 }
 
+func TestErrInvalidBaseURL(t *testing.T) {
+	_, err := NewAPI("secret", BaseURLOption("not a url"))
+
+	if !errors.Is(err, ErrInvalidBaseURL) {
+		t.Errorf("Malformed base URL should return ErrInvalidBaseURL, got %v", err)
+	}
+}
+
+func TestBaseURLOption(t *testing.T) {
+	var requested string
+
+	client := HTTPClientFuncMock(func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+
+		return ClientMock.Do(req)
+	})
+
+	api, err := NewAPI("test-secret", HTTPClientOption(client), BaseURLOption("http://127.0.0.1:8080"))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	d, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	validateForecast(t, d)
+
+	if expected := "http://127.0.0.1:8080/forecast/test-secret/37.8267,-122.4233"; requested != expected {
+		t.Errorf("Request should have been sent to %s, %s given", expected, requested)
+	}
+}
+
 func TestLoggerOption(t *testing.T) {
 	w := &logWriter{}
 	logger := log.New(w, "darksky test - ", log.LstdFlags)
@@ -248,7 +387,7 @@ func TestCloseDefer(t *testing.T) {
 	writer := &logWriter{}
 	logger := log.New(writer, "darksky test - ", log.LstdFlags)
 
-	close(closer, logger)
+	closeLogged(closer, logger)
 
 	if !strings.Contains(string(writer.res), "darksky test - ") && !strings.Contains(string(writer.res), "Test error") {
 		t.Error("Closer is returning an error, should have been logged in logger from function parameter.")
@@ -365,7 +504,7 @@ func validateForecast(t *testing.T, d *APIData) {
 
 	assertInt(t, "Currently.Time", d.Currently.Time, 1544378256)
 	assertString(t, "Currently.Summary", d.Currently.Summary, "Overcast")
-	assertString(t, "Currently.Icon", d.Currently.Icon, "cloudy")
+	assertString(t, "Currently.Icon", string(d.Currently.Icon), "cloudy")
 	assertInt(t, "Currently.NearestStormDistance", d.Currently.NearestStormDistance, 12)
 	assertInt(t, "Currently.NearestStormBearing", d.Currently.NearestStormBearing, 83)
 	assertFloat(t, "Currently.PrecipIntensity", d.Currently.PrecipIntensity, 0)
@@ -384,16 +523,16 @@ func validateForecast(t *testing.T, d *APIData) {
 	assertFloat(t, "currently.Ozone", d.Currently.Ozone, 272.39)
 
 	assertString(t, "Minutely.Summary", d.Minutely.Summary, "Overcast for the hour.")
-	assertString(t, "Minutely.Icon", d.Minutely.Icon, "cloudy")
+	assertString(t, "Minutely.Icon", string(d.Minutely.Icon), "cloudy")
 	assertInt(t, "Minutely.Data[0].Time", d.Minutely.Data[0].Time, 1544378220)
 	assertFloat(t, "Minutely.Data[0].PrecipIntensity", d.Minutely.Data[0].PrecipIntensity, 0)
 	assertFloat(t, "Minutely.Data[0].PrecipProbability", d.Minutely.Data[0].PrecipProbability, 0)
 
 	assertString(t, "Hourly.Summary", d.Hourly.Summary, "Mostly cloudy until tomorrow morning.")
-	assertString(t, "Hourly.Icon", d.Hourly.Icon, "partly-cloudy-night")
+	assertString(t, "Hourly.Icon", string(d.Hourly.Icon), "partly-cloudy-night")
 	assertInt(t, "Hourly.Data[0].Time", d.Hourly.Data[0].Time, 1544374800)
 	assertString(t, "Hourly.Data[0].Summary", d.Hourly.Data[0].Summary, "Mostly Cloudy")
-	assertString(t, "Hourly.Data[0].Icon", d.Hourly.Data[0].Icon, "partly-cloudy-day")
+	assertString(t, "Hourly.Data[0].Icon", string(d.Hourly.Data[0].Icon), "partly-cloudy-day")
 	assertFloat(t, "Hourly.Data[0].PrecipIntensity", d.Hourly.Data[0].PrecipIntensity, 0)
 	assertFloat(t, "Hourly.Data[0].PrecipProbability", d.Hourly.Data[0].PrecipProbability, 0)
 	assertFloat(t, "Hourly.Data[0].Temperature", d.Hourly.Data[0].Temperature, 47.7)
@@ -410,10 +549,10 @@ func validateForecast(t *testing.T, d *APIData) {
 	assertFloat(t, "Hourly.Data[0].Ozone", d.Hourly.Data[0].Ozone, 270.82)
 
 	assertString(t, "Daily.Summary", d.Daily.Summary, "Rain tomorrow and next Sunday, with high temperatures peaking at 60°F on Wednesday.")
-	assertString(t, "Daily.Icon", d.Daily.Icon, "rain")
+	assertString(t, "Daily.Icon", string(d.Daily.Icon), "rain")
 	assertInt(t, "Daily.Data[0].Time", d.Daily.Data[0].Time, 1544342400)
 	assertString(t, "Daily.Data[0].Summary", d.Daily.Data[0].Summary, "Mostly cloudy throughout the day.")
-	assertString(t, "Daily.Data[0].Icon", d.Daily.Data[0].Icon, "partly-cloudy-day")
+	assertString(t, "Daily.Data[0].Icon", string(d.Daily.Data[0].Icon), "partly-cloudy-day")
 	assertInt(t, "Daily.Data[0].SunriseTime", d.Daily.Data[0].SunriseTime, 1544368517)
 	assertInt(t, "Daily.Data[0].SunsetTime", d.Daily.Data[0].SunsetTime, 1544403121)
 	assertFloat(t, "Daily.Data[0].MoonPhase", d.Daily.Data[0].MoonPhase, 0.08)
@@ -421,7 +560,7 @@ func validateForecast(t *testing.T, d *APIData) {
 	assertFloat(t, "Daily.Data[0].PrecipIntensityMax", d.Daily.Data[0].PrecipIntensityMax, 0.0018)
 	assertInt(t, "Daily.Data[0].PrecipIntensityMaxTime", d.Daily.Data[0].PrecipIntensityMaxTime, 1544407200)
 	assertFloat(t, "Daily.Data[0].PrecipProbability", d.Daily.Data[0].PrecipProbability, 0.19)
-	assertString(t, "Daily.Data[0].PrecipType", d.Daily.Data[0].PrecipType, "rain")
+	assertString(t, "Daily.Data[0].PrecipType", string(d.Daily.Data[0].PrecipType), "rain")
 	assertFloat(t, "Daily.Data[0].TemperatureHigh", d.Daily.Data[0].TemperatureHigh, 54.7)
 	assertInt(t, "Daily.Data[0].TemperatureHighTime", d.Daily.Data[0].TemperatureHighTime, 1544400000)
 	assertFloat(t, "Daily.Data[0].TemperatureLow", d.Daily.Data[0].TemperatureLow, 48.78)
@@ -447,7 +586,7 @@ func validateForecast(t *testing.T, d *APIData) {
 	assertInt(t, "Alerts[0].Expires", d.Alerts[0].Expires, 1544371200)
 	assertString(t, "Alerts[0].Regions[0]", d.Alerts[0].Regions[0], "ca")
 	assertString(t, "Alerts[0].Regions[1]", d.Alerts[0].Regions[1], "us")
-	assertString(t, "Alerts[0].Severity", d.Alerts[0].Severity, "watch")
+	assertString(t, "Alerts[0].Severity", string(d.Alerts[0].Severity), "watch")
 	assertInt(t, "Alerts[0].Time", d.Alerts[0].Time, 1544371200)
 	assertString(t, "Alerts[0].Title", d.Alerts[0].Title, "Alert title")
 	assertString(t, "Alerts[0].URI", d.Alerts[0].URI, "https://www.darksky.net")
@@ -474,7 +613,7 @@ func validateTimeMachine(t *testing.T, d *APIData) {
 
 	assertInt(t, "Currently.Time", d.Currently.Time, 255657600)
 	assertString(t, "Currently.Summary", d.Currently.Summary, "Mostly Cloudy")
-	assertString(t, "Currently.Icon", d.Currently.Icon, "partly-cloudy-day")
+	assertString(t, "Currently.Icon", string(d.Currently.Icon), "partly-cloudy-day")
 	assertFloat(t, "Currently.PrecipIntensity", d.Currently.PrecipIntensity, 0)
 	assertFloat(t, "Currently.PrecipProbability", d.Currently.PrecipProbability, 0)
 	assertFloat(t, "currently.Temperature", d.Currently.Temperature, 60.46)
@@ -489,10 +628,10 @@ func validateTimeMachine(t *testing.T, d *APIData) {
 	assertFloat(t, "currently.Visibility", d.Currently.Visibility, 7)
 
 	assertString(t, "Hourly.Summary", d.Hourly.Summary, "Rain overnight and in the morning and breezy in the morning.")
-	assertString(t, "Hourly.Icon", d.Hourly.Icon, "rain")
+	assertString(t, "Hourly.Icon", string(d.Hourly.Icon), "rain")
 	assertInt(t, "Hourly.Data[0].Time", d.Hourly.Data[0].Time, 255600000)
 	assertString(t, "Hourly.Data[0].Summary", d.Hourly.Data[0].Summary, "Overcast")
-	assertString(t, "Hourly.Data[0].Icon", d.Hourly.Data[0].Icon, "cloudy")
+	assertString(t, "Hourly.Data[0].Icon", string(d.Hourly.Data[0].Icon), "cloudy")
 	assertFloat(t, "Hourly.Data[0].PrecipIntensity", d.Hourly.Data[0].PrecipIntensity, 0)
 	assertFloat(t, "Hourly.Data[0].PrecipProbability", d.Hourly.Data[0].PrecipProbability, 0)
 	assertFloat(t, "Hourly.Data[0].Temperature", d.Hourly.Data[0].Temperature, 55.34)
@@ -508,7 +647,7 @@ func validateTimeMachine(t *testing.T, d *APIData) {
 
 	assertInt(t, "Daily.Data[0].Time", d.Daily.Data[0].Time, 255600000)
 	assertString(t, "Daily.Data[0].Summary", d.Daily.Data[0].Summary, "Rain and breezy in the morning.")
-	assertString(t, "Daily.Data[0].Icon", d.Daily.Data[0].Icon, "rain")
+	assertString(t, "Daily.Data[0].Icon", string(d.Daily.Data[0].Icon), "rain")
 	assertInt(t, "Daily.Data[0].SunriseTime", d.Daily.Data[0].SunriseTime, 255625832)
 	assertInt(t, "Daily.Data[0].SunsetTime", d.Daily.Data[0].SunsetTime, 255663586)
 	assertFloat(t, "Daily.Data[0].MoonPhase", d.Daily.Data[0].MoonPhase, 0.97)
@@ -516,7 +655,7 @@ func validateTimeMachine(t *testing.T, d *APIData) {
 	assertFloat(t, "Daily.Data[0].PrecipIntensityMax", d.Daily.Data[0].PrecipIntensityMax, 0.1692)
 	assertInt(t, "Daily.Data[0].PrecipIntensityMaxTime", d.Daily.Data[0].PrecipIntensityMaxTime, 255625200)
 	assertFloat(t, "Daily.Data[0].PrecipProbability", d.Daily.Data[0].PrecipProbability, 1)
-	assertString(t, "Daily.Data[0].PrecipType", d.Daily.Data[0].PrecipType, "rain")
+	assertString(t, "Daily.Data[0].PrecipType", string(d.Daily.Data[0].PrecipType), "rain")
 	assertFloat(t, "Daily.Data[0].TemperatureHigh", d.Daily.Data[0].TemperatureHigh, 60.75)
 	assertInt(t, "Daily.Data[0].TemperatureHighTime", d.Daily.Data[0].TemperatureHighTime, 255650400)
 	assertFloat(t, "Daily.Data[0].TemperatureLow", d.Daily.Data[0].TemperatureLow, 54.78)
@@ -548,6 +687,45 @@ func validateTimeMachine(t *testing.T, d *APIData) {
 	assertString(t, "Flags.Units", d.Flags.Units, "us")
 }
 
+func TestGoldenPayloads(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Missing golden payloads: %v", err)
+	}
+
+	for _, file := range files {
+		golden, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var data APIData
+
+		if err := json.Unmarshal(golden, &data); err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		content, err := json.Marshal(data)
+
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+
+		var expected, got interface{}
+
+		json.Unmarshal(golden, &expected)
+		json.Unmarshal(content, &got)
+
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("%s: payload changed after decoding and encoding it again, got %s", file, content)
+		}
+	}
+}
+
 func assertInt(t *testing.T, name string, value, expected int64) {
 	if value != expected {
 		t.Errorf("Field %s expected to be %d, got %d", name, expected, value)
@@ -0,0 +1,230 @@
+package darkskytest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/averagegeek/darksky"
+)
+
+// redacted replaces the secret in the URLs written to fixtures.
+const redacted = "REDACTED"
+
+// Mode of a Recorder.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the fixtures, failing on requests without fixture.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests through another HTTPClient and writes the fixtures.
+	ModeRecord
+)
+
+var (
+	// ErrFixtureNotFound is returned in replay mode for a request without fixture.
+	ErrFixtureNotFound = errors.New("no fixture recorded for request")
+
+	// ErrNilHTTPClient occurs when creating a recorder in record mode without client.
+	ErrNilHTTPClient = errors.New("HTTP client provided cannot be nil in record mode")
+)
+
+// Recorder is a darksky.HTTPClient recording request/response pairs to fixture files, or
+// replaying them. Requests are matched on their path, with the secret redacted, and their query
+// string, options order not mattering. It is safe for concurrent use.
+type Recorder struct {
+	mode   Mode
+	dir    string
+	client darksky.HTTPClient
+	mu     sync.Mutex
+}
+
+// Fixture is a recorded request/response pair, as stored in a file.
+type Fixture struct {
+	Method string `json:"method"`
+	// URL of the request, normalized and with the secret redacted.
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	// Body of the response, uncompressed.
+	Body string `json:"body"`
+}
+
+// NewRecorder creates a recorder using the fixtures of dir. In record mode, requests are sent
+// through client and dir is created if needed.
+func NewRecorder(mode Mode, dir string, client darksky.HTTPClient) (*Recorder, error) {
+	if mode == ModeRecord {
+		if client == nil {
+			return nil, ErrNilHTTPClient
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Recorder{mode: mode, dir: dir, client: client}, nil
+}
+
+// Do implements darksky.HTTPClient.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	key := fixtureURL(req.URL)
+
+	if r.mode == ModeReplay {
+		f, err := r.load(req.Method, key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return f.response(req), nil
+	}
+
+	resp, err := r.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFixture(req.Method, key, resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.save(f); err != nil {
+		return nil, err
+	}
+
+	return f.response(req), nil
+}
+
+func (r *Recorder) load(method, key string) (*Fixture, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := ioutil.ReadFile(r.path(method, key))
+
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s %s in %s", ErrFixtureNotFound, method, key, r.dir)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixture
+
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (r *Recorder) save(f *Fixture) error {
+	content, err := json.MarshalIndent(f, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return ioutil.WriteFile(r.path(f.Method, f.URL), content, 0644)
+}
+
+// path of the fixture, named after the coordinates for readability and a hash of the request.
+func (r *Recorder) path(method, key string) string {
+	sum := sha256.Sum256([]byte(method + " " + key))
+	u, _ := url.Parse(key)
+	name := strings.NewReplacer(",", "_", ".", "-").Replace(filepath.Base(u.Path))
+
+	return filepath.Join(r.dir, name+"-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// fixtureURL normalizes the URL of a request: the host is dropped, the secret redacted, and the
+// query string sorted, including the exclude list.
+func fixtureURL(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+
+	if len(segments) >= 2 {
+		segments[len(segments)-2] = redacted
+	}
+
+	q := u.Query()
+
+	if ex := q.Get("exclude"); ex != "" {
+		blocks := strings.Split(strings.Trim(ex, "[]"), ",")
+		sort.Strings(blocks)
+		q.Set("exclude", "["+strings.Join(blocks, ",")+"]")
+	}
+
+	n := url.URL{Path: strings.Join(segments, "/"), RawQuery: q.Encode()}
+
+	return n.String()
+}
+
+func newFixture(method, key string, resp *http.Response) (*Fixture, error) {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+
+	if header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if body, err = ioutil.ReadAll(gr); err != nil {
+			return nil, err
+		}
+
+		header.Del("Content-Encoding")
+	}
+
+	header.Del("Content-Length")
+
+	return &Fixture{
+		Method:     method,
+		URL:        key,
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	}, nil
+}
+
+// response rebuilds the recorded response, uncompressed.
+func (f *Fixture) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,133 @@
+package darkskytest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/averagegeek/darksky"
+)
+
+func newFixtureDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "darkskytest-fixtures")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, cleanup := newFixtureDir(t)
+	defer cleanup()
+
+	s := NewServer("my-secret", GzipOption())
+	defer s.Close()
+
+	rec, err := NewRecorder(ModeRecord, dir, nil)
+
+	if err != ErrNilHTTPClient {
+		t.Errorf("Record mode without client should return ErrNilHTTPClient, got %v", err)
+	}
+
+	rec, err = NewRecorder(ModeRecord, dir, http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := newAPI(t, s, darksky.HTTPClientOption(rec))
+	at := time.Date(2019, 3, 1, 15, 0, 0, 0, time.UTC)
+
+	recorded, err := api.TimeMachine(42.3601, -71.0589, at, darksky.ExcludeOption(darksky.ExMinutely, darksky.ExHourly), darksky.UnitOption(darksky.UnitSI))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	if err != nil || len(files) != 1 {
+		t.Fatalf("One fixture should have been written, got %v (%v)", files, err)
+	}
+
+	content, err := ioutil.ReadFile(files[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "my-secret") || !strings.Contains(string(content), "/forecast/REDACTED/42.3601,-71.0589,1551452400") {
+		t.Errorf("Fixture should have the secret redacted, got %s", content)
+	}
+
+	s.Close()
+
+	replay, err := NewRecorder(ModeReplay, dir, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err = darksky.NewAPI("another-secret", darksky.HTTPClientOption(replay))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := api.TimeMachine(42.3601, -71.0589, at, darksky.UnitOption(darksky.UnitSI), darksky.ExcludeOption(darksky.ExHourly, darksky.ExMinutely))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replayed.Currently.Time != recorded.Currently.Time || replayed.Daily.Data[0].Temperature != recorded.Daily.Data[0].Temperature {
+		t.Error("Replayed data should be the recorded one")
+	}
+
+	if replayed.Metadata.APICalls != 1 {
+		t.Errorf("Replayed headers should be the recorded ones, got %v", replayed.Metadata.Header)
+	}
+
+	_, err = api.Forecast(42.3601, -71.0589)
+
+	if !errors.Is(err, ErrFixtureNotFound) || !strings.Contains(err.Error(), "/forecast/REDACTED/42.3601,-71.0589") {
+		t.Errorf("Unmatched request should fail with ErrFixtureNotFound, got %v", err)
+	}
+}
+
+func TestReplayErrorResponse(t *testing.T) {
+	dir, cleanup := newFixtureDir(t)
+	defer cleanup()
+
+	s := NewServer("my-secret")
+	defer s.Close()
+
+	s.Enqueue(Response{StatusCode: 400, Body: `{"code":400,"error":"The given location is invalid."}`, ContentType: "application/json"})
+
+	rec, err := NewRecorder(ModeRecord, dir, http.DefaultClient)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newAPI(t, s, darksky.HTTPClientOption(rec)).Forecast(1, 1); !errors.Is(err, darksky.ErrBadRequest) {
+		t.Fatalf("Should have recorded a bad request, got %v", err)
+	}
+
+	replay, err := NewRecorder(ModeReplay, dir, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newAPI(t, s, darksky.HTTPClientOption(replay)).Forecast(1, 1); !errors.Is(err, darksky.ErrBadRequest) {
+		t.Errorf("Should have replayed the bad request, got %v", err)
+	}
+}
@@ -0,0 +1,437 @@
+// Package darkskytest provides a fake Dark Sky server to test code using the darksky package
+// without reaching the network.
+package darkskytest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/averagegeek/darksky"
+)
+
+// Path of the queries, as built by the darksky package: /forecast/<secret>/<lat>,<lng>[,<time>]
+// with coordinates formatted with 4 decimals.
+var pathPattern = regexp.MustCompile(`^/forecast/([^/]+)/(-?\d+\.\d{4}),(-?\d+\.\d{4})(?:,(-?\d+))?$`)
+
+// errInvalidQuery is the message of the API for queries it cannot understand.
+var errInvalidQuery = errors.New("The given location (or time) is invalid.")
+
+// Query is a query received by the server, as understood from its URL.
+type Query struct {
+	Lat float64
+	Lng float64
+	// Time of a time machine query, zero for a forecast.
+	Time time.Time
+	// Values of the query string, like lang, units, exclude and extend.
+	Values url.Values
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	// Secret found in the path, empty when the path is malformed.
+	Secret string
+	// Query found in the path, nil when the path is malformed.
+	Query *Query
+}
+
+// Response scripts the answer to a request.
+type Response struct {
+	// StatusCode of the response. Defaults to 200.
+	StatusCode int
+	// Data is encoded as the JSON body when set.
+	Data *darksky.APIData
+	// Body is sent as is when Data is nil, with ContentType.
+	Body        string
+	ContentType string
+	// Header is added to the response.
+	Header http.Header
+}
+
+// Generator creates the data answering a query when no response is scripted.
+type Generator func(Query) *darksky.APIData
+
+// Server is a fake Dark Sky API. It checks the secret and the path of each request, answers with
+// the scripted responses in order, then with generated data. It is safe for concurrent use.
+type Server struct {
+	// URL of the server, to pass to darksky.BaseURLOption.
+	URL string
+
+	srv       *httptest.Server
+	secret    string
+	mu        sync.Mutex
+	scripted  []Response
+	requests  []Request
+	generator Generator
+	gzip      bool
+	latency   time.Duration
+	quota     int
+	calls     int
+}
+
+// ServerOption to override defaults of the server.
+type ServerOption func(*Server)
+
+// GzipOption to compress the responses when the request accepts it, like the real API does.
+func GzipOption() ServerOption {
+	return func(s *Server) {
+		s.gzip = true
+	}
+}
+
+// LatencyOption to delay every response.
+func LatencyOption(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// QuotaOption to answer 403 "daily usage limit exceeded", like the real API, once limit calls
+// were made. Every response carries the X-Forecast-API-Calls header anyway.
+func QuotaOption(limit int) ServerOption {
+	return func(s *Server) {
+		s.quota = limit
+	}
+}
+
+// GeneratorOption to replace the default generator of data.
+func GeneratorOption(g Generator) ServerOption {
+	return func(s *Server) {
+		s.generator = g
+	}
+}
+
+// NewServer starts a server accepting the given secret. It must be closed once done.
+func NewServer(secret string, opts ...ServerOption) *Server {
+	s := &Server{secret: secret, generator: Generate}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+
+	return s
+}
+
+// NewAPI creates an API querying the server with its secret.
+func (s *Server) NewAPI(opts ...darksky.APIOption) (*darksky.API, error) {
+	return darksky.NewAPI(s.secret, append([]darksky.APIOption{darksky.BaseURLOption(s.URL)}, opts...)...)
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Enqueue scripts responses, served in order to the next requests with a valid path and secret.
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripted = append(s.scripted, responses...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Calls returns the number of valid queries received so far, as reported in the
+// X-Forecast-API-Calls header.
+func (s *Server) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	req := Request{Method: r.Method, URL: r.URL, Header: r.Header}
+	resp := s.respond(&req)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	s.write(w, r, resp)
+}
+
+// respond validates the request and picks its response.
+func (s *Server) respond(req *Request) Response {
+	if req.Method != http.MethodGet {
+		return errorResponse(http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+
+	m := pathPattern.FindStringSubmatch(req.URL.Path)
+
+	if m == nil {
+		return textResponse(http.StatusNotFound, "Not Found")
+	}
+
+	req.Secret = m[1]
+
+	q, err := parseQuery(m[2], m[3], m[4], req.URL.Query())
+
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+
+	req.Query = q
+
+	if req.Secret != s.secret {
+		return textResponse(http.StatusForbidden, "Forbidden")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.quota > 0 && s.calls >= s.quota {
+		return withCalls(errorResponse(http.StatusForbidden, "daily usage limit exceeded"), s.calls)
+	}
+
+	s.calls++
+
+	if len(s.scripted) > 0 {
+		resp := s.scripted[0]
+		s.scripted = s.scripted[1:]
+
+		return withCalls(resp, s.calls)
+	}
+
+	return withCalls(Response{Data: s.generator(*q)}, s.calls)
+}
+
+func parseQuery(lat, lng, ts string, values url.Values) (*Query, error) {
+	q := &Query{Values: values}
+
+	q.Lat, _ = strconv.ParseFloat(lat, 64)
+	q.Lng, _ = strconv.ParseFloat(lng, 64)
+
+	if q.Lat < -90 || q.Lat > 90 || q.Lng < -180 || q.Lng > 180 {
+		return nil, errInvalidQuery
+	}
+
+	if ts != "" {
+		sec, err := strconv.ParseInt(ts, 10, 64)
+
+		if err != nil {
+			return nil, errInvalidQuery
+		}
+
+		q.Time = time.Unix(sec, 0).UTC()
+	}
+
+	for key := range values {
+		switch key {
+		case "lang", "units", "exclude", "extend":
+		default:
+			return nil, errInvalidQuery
+		}
+	}
+
+	return q, nil
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request, resp Response) {
+	body := []byte(resp.Body)
+	contentType := resp.ContentType
+
+	if resp.Data != nil {
+		var err error
+
+		if body, err = json.Marshal(resp.Data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		contentType = "application/json; charset=utf-8"
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if s.gzip && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		var buf bytes.Buffer
+
+		gw := gzip.NewWriter(&buf)
+
+		if _, err := gw.Write(body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := gw.Close(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		body = buf.Bytes()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	status := resp.StatusCode
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func textResponse(status int, message string) Response {
+	return Response{StatusCode: status, Body: message, ContentType: "text/plain"}
+}
+
+// errorResponse is an error with the JSON payload of the API.
+func errorResponse(status int, message string) Response {
+	body, _ := json.Marshal(struct {
+		Code  int    `json:"code"`
+		Error string `json:"error"`
+	}{status, message})
+
+	return Response{StatusCode: status, Body: string(body), ContentType: "application/json; charset=utf-8"}
+}
+
+func withCalls(resp Response, calls int) Response {
+	h := make(http.Header)
+
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+
+	if h.Get("X-Forecast-API-Calls") == "" {
+		h.Set("X-Forecast-API-Calls", strconv.Itoa(calls))
+	}
+
+	if h.Get("X-Response-Time") == "" {
+		h.Set("X-Response-Time", "1.000ms")
+	}
+
+	resp.Header = h
+
+	return resp
+}
+
+// Generate is the default generator. It creates plausible data for the query: the current
+// conditions, 49 hours and 8 days starting at the time of the query, or at the current time for
+// a forecast, honoring the units and exclude parameters. Auto units are resolved to si.
+func Generate(q Query) *darksky.APIData {
+	t := q.Time
+
+	if t.IsZero() {
+		t = time.Now().UTC()
+	}
+
+	units := q.Values.Get("units")
+
+	switch units {
+	case "":
+		units = darksky.UnitUS
+	case darksky.UnitAuto:
+		// Like the real API, auto is resolved to the system of the location, always si here.
+		units = darksky.UnitSI
+	}
+
+	hour := t.Truncate(time.Hour)
+	day := t.Truncate(24 * time.Hour)
+
+	data := &darksky.APIData{
+		Latitude:  q.Lat,
+		Longitude: q.Lng,
+		Timezone:  "Etc/UTC",
+		Currently: generatePoint(t, units),
+		Hourly:    darksky.DataBlock{Summary: "Partly cloudy throughout the day.", Icon: darksky.IconPartlyCloudyDay},
+		Daily:     darksky.DataBlock{Summary: "No precipitation throughout the week.", Icon: darksky.IconClearDay},
+		Flags:     darksky.Flags{Sources: []string{"darkskytest"}, Units: units},
+	}
+
+	for i := 0; i < 49; i++ {
+		data.Hourly.Data = append(data.Hourly.Data, generatePoint(hour.Add(time.Duration(i)*time.Hour), units))
+	}
+
+	for i := 0; i < 8; i++ {
+		p := generatePoint(day.AddDate(0, 0, i), units)
+		p.SunriseTime = day.AddDate(0, 0, i).Add(6 * time.Hour).Unix()
+		p.SunsetTime = day.AddDate(0, 0, i).Add(18 * time.Hour).Unix()
+		p.TemperatureHigh = p.Temperature + 5
+		p.TemperatureLow = p.Temperature - 5
+		p.TemperatureMax = p.TemperatureHigh
+		p.TemperatureMin = p.TemperatureLow
+		data.Daily.Data = append(data.Daily.Data, p)
+	}
+
+	for _, ex := range strings.Split(strings.Trim(q.Values.Get("exclude"), "[]"), ",") {
+		switch ex {
+		case darksky.ExCurrently:
+			data.Currently = darksky.DataPoint{}
+		case darksky.ExHourly:
+			data.Hourly = darksky.DataBlock{}
+		case darksky.ExDaily:
+			data.Daily = darksky.DataBlock{}
+		case darksky.ExFlags:
+			data.Flags = darksky.Flags{}
+		}
+	}
+
+	return data
+}
+
+// generatePoint makes a temperature following the time of the day, in the given units.
+func generatePoint(t time.Time, units string) darksky.DataPoint {
+	celsius := 15 + 8*math.Sin(2*math.Pi*float64(t.Hour()-9)/24)
+	temperature := celsius
+
+	if units == darksky.UnitUS {
+		temperature = celsius*9/5 + 32
+	}
+
+	temperature = math.Round(temperature*100) / 100
+
+	return darksky.DataPoint{
+		Time:                t.Unix(),
+		Summary:             "Partly Cloudy",
+		Icon:                darksky.IconPartlyCloudyDay,
+		Temperature:         temperature,
+		ApparentTemperature: temperature,
+		Humidity:            0.6,
+		CloudCover:          0.4,
+		Pressure:            1015,
+		WindSpeed:           3.5,
+		WindBearing:         270,
+		PrecipProbability:   0.1,
+		UvIndex:             2,
+		Visibility:          10,
+	}
+}
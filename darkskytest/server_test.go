@@ -0,0 +1,238 @@
+package darkskytest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/averagegeek/darksky"
+)
+
+func newAPI(t *testing.T, s *Server, opts ...darksky.APIOption) *darksky.API {
+	api, err := s.NewAPI(opts...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return api
+}
+
+func TestServerGeneratesForecast(t *testing.T) {
+	s := NewServer("secret", GzipOption())
+	defer s.Close()
+
+	d, err := newAPI(t, s).Forecast(37.8267, -122.4233, darksky.UnitOption(darksky.UnitSI), darksky.ExcludeOption(darksky.ExDaily))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Latitude != 37.8267 || d.Longitude != -122.4233 {
+		t.Errorf("Coordinates should be those of the query, got %f,%f", d.Latitude, d.Longitude)
+	}
+
+	if d.Flags.Units != darksky.UnitSI {
+		t.Errorf("Units should be si, got %s", d.Flags.Units)
+	}
+
+	if len(d.Hourly.Data) != 49 || len(d.Daily.Data) != 0 {
+		t.Errorf("Should have 49 hours and no day, got %d and %d", len(d.Hourly.Data), len(d.Daily.Data))
+	}
+
+	if d.Metadata.APICalls != 1 {
+		t.Errorf("X-Forecast-API-Calls should be 1, got %d", d.Metadata.APICalls)
+	}
+
+	reqs := s.Requests()
+
+	if len(reqs) != 1 {
+		t.Fatalf("Server should have recorded 1 request, got %d", len(reqs))
+	}
+
+	if reqs[0].Secret != "secret" || !reqs[0].Query.Time.IsZero() || reqs[0].Query.Values.Get("units") != "si" {
+		t.Errorf("Request should be a forecast in si units, got %+v", reqs[0])
+	}
+
+	if reqs[0].Header.Get("Accept-Encoding") != "gzip" {
+		t.Error("Client should have accepted gzip")
+	}
+}
+
+func TestServerResolvesAutoUnits(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	d, err := newAPI(t, s).Forecast(37.8267, -122.4233, darksky.UnitOption(darksky.UnitAuto))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Flags.Units != darksky.UnitSI {
+		t.Errorf("Units should be resolved to si, got %s", d.Flags.Units)
+	}
+
+	if d.Currently.Temperature < 7 || d.Currently.Temperature > 23 {
+		t.Errorf("Temperature should be in Celsius, got %f", d.Currently.Temperature)
+	}
+
+	if _, err := d.Convert(darksky.UnitUS); err != nil {
+		t.Errorf("Data should be convertible, got %v", err)
+	}
+}
+
+func TestServerGeneratesTimeMachine(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	at := time.Date(2019, 3, 1, 15, 0, 0, 0, time.UTC)
+
+	d, err := newAPI(t, s).TimeMachine(45.5017, -73.5673, at)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Currently.Time != at.Unix() || d.Daily.Data[0].Time != at.Truncate(24*time.Hour).Unix() {
+		t.Errorf("Data should be generated at the time of the query, got %d", d.Currently.Time)
+	}
+
+	if q := s.Requests()[0].Query; !q.Time.Equal(at) {
+		t.Errorf("Query time should be %s, got %s", at, q.Time)
+	}
+}
+
+func TestServerScriptedResponses(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	s.Enqueue(
+		Response{Data: &darksky.APIData{Timezone: "America/New_York"}, Header: http.Header{"Cache-Control": []string{"max-age=60"}}},
+		Response{StatusCode: http.StatusServiceUnavailable, Body: "Service Unavailable", ContentType: "text/plain"},
+	)
+
+	api := newAPI(t, s)
+
+	d, err := api.Forecast(42.3601, -71.0589)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Timezone != "America/New_York" || d.Metadata.CacheControl != "max-age=60" {
+		t.Errorf("First scripted response should have been served, got %+v", d)
+	}
+
+	if _, err := api.Forecast(42.3601, -71.0589); !errors.Is(err, darksky.ErrServerError) {
+		t.Errorf("Second scripted response should be a server error, got %v", err)
+	}
+
+	if _, err := api.Forecast(42.3601, -71.0589); err != nil {
+		t.Errorf("Generated data should be served once the script is over, got %v", err)
+	}
+}
+
+func TestServerRejectsWrongSecret(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	api, err := darksky.NewAPI("other", darksky.BaseURLOption(s.URL))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(42.3601, -71.0589); !errors.Is(err, darksky.ErrUnauthorized) {
+		t.Errorf("Wrong secret should be unauthorized, got %v", err)
+	}
+
+	if s.Calls() != 0 {
+		t.Error("Unauthorized requests should not count as calls")
+	}
+}
+
+func TestServerRejectsMalformedRequests(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	paths := map[string]int{
+		"/forecast/secret/37.8267,-122.4233":          http.StatusOK,
+		"/forecast/secret/37.82,-122.4233":            http.StatusNotFound,
+		"/forecast/secret/37.8267":                    http.StatusNotFound,
+		"/v2/forecast/secret/37.8267,-122.4233":       http.StatusNotFound,
+		"/forecast/secret/137.8267,-122.4233":         http.StatusBadRequest,
+		"/forecast/secret/37.8267,-122.4233?foo=bar":  http.StatusBadRequest,
+		"/forecast/secret/37.8267,-122.4233,15000000": http.StatusOK,
+	}
+
+	for path, expected := range paths {
+		resp, err := http.Get(s.URL + path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Errorf("%s should answer %d, got %d", path, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerQuota(t *testing.T) {
+	s := NewServer("secret", QuotaOption(2))
+	defer s.Close()
+
+	api := newAPI(t, s)
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Forecast(42.3601, -71.0589); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := api.Forecast(42.3601, -71.0589)
+
+	var apiErr *darksky.APIError
+
+	if !errors.As(err, &apiErr) || !errors.Is(err, darksky.ErrQuotaExhausted) {
+		t.Fatalf("Should have exhausted the quota, got %v", err)
+	}
+
+	if apiErr.Header.Get("X-Forecast-API-Calls") != "2" {
+		t.Errorf("X-Forecast-API-Calls should be 2, got %s", apiErr.Header.Get("X-Forecast-API-Calls"))
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	s := NewServer("secret", LatencyOption(time.Second))
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := newAPI(t, s).ForecastContext(ctx, 42.3601, -71.0589); err != context.DeadlineExceeded {
+		t.Errorf("Should have timed out, got %v", err)
+	}
+}
+
+func TestServerGenerator(t *testing.T) {
+	s := NewServer("secret", GeneratorOption(func(q Query) *darksky.APIData {
+		return &darksky.APIData{Timezone: q.Values.Get("lang")}
+	}))
+	defer s.Close()
+
+	d, err := newAPI(t, s).Forecast(42.3601, -71.0589, darksky.LanguageOption(darksky.LangFR))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Timezone != "fr" {
+		t.Errorf("Custom generator should have been used, got %+v", d)
+	}
+}
@@ -0,0 +1,326 @@
+package darksky
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Diff is the difference between two responses for the same location.
+type Diff struct {
+	Currently []FieldChange `json:"currently,omitempty"`
+	Hourly    BlockDiff     `json:"hourly"`
+	Daily     BlockDiff     `json:"daily"`
+	Alerts    AlertsDiff    `json:"alerts"`
+
+	loc *time.Location
+}
+
+// BlockDiff is the difference between two data blocks, their data points aligned by time.
+type BlockDiff struct {
+	Changed []PointDiff `json:"changed,omitempty"`
+	Added   []DataPoint `json:"added,omitempty"`
+	Removed []DataPoint `json:"removed,omitempty"`
+}
+
+// PointDiff is the difference between two data points at the same time.
+type PointDiff struct {
+	Time    int64         `json:"time"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is the change of a field of a data point, named as in the JSON payload. Old or New
+// is nil when the field is missing. Delta is New minus Old for numbers, rounded to 6 decimals.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+	Delta float64     `json:"delta,omitempty"`
+}
+
+// AlertsDiff is the difference between two lists of alerts, identified by URI and title.
+type AlertsDiff struct {
+	Added   Alerts `json:"added,omitempty"`
+	Removed Alerts `json:"removed,omitempty"`
+	// Updated are the alerts with another time, expiry, severity, description or regions.
+	Updated Alerts `json:"updated,omitempty"`
+}
+
+// DiffData compares two responses for the same location, from the older to the newer. Extra
+// properties of the data points are compared too.
+func DiffData(from, to *APIData) *Diff {
+	return &Diff{
+		Currently: diffPoints(from.Currently, to.Currently),
+		Hourly:    diffBlocks(from.Hourly, to.Hourly),
+		Daily:     diffBlocks(from.Daily, to.Daily),
+		Alerts:    diffAlerts(from.Alerts, to.Alerts),
+		loc:       to.Location(),
+	}
+}
+
+// Empty tells whether the responses are the same.
+func (d *Diff) Empty() bool {
+	return len(d.Currently) == 0 && d.Hourly.empty() && d.Daily.empty() &&
+		len(d.Alerts.Added) == 0 && len(d.Alerts.Removed) == 0 && len(d.Alerts.Updated) == 0
+}
+
+func (b BlockDiff) empty() bool {
+	return len(b.Changed) == 0 && len(b.Added) == 0 && len(b.Removed) == 0
+}
+
+func diffBlocks(from, to DataBlock) BlockDiff {
+	var d BlockDiff
+
+	previous := make(map[int64]DataPoint)
+
+	for _, p := range from.Data {
+		previous[p.Time] = p
+	}
+
+	for _, p := range to.Data {
+		prev, ok := previous[p.Time]
+
+		if !ok {
+			d.Added = append(d.Added, p)
+			continue
+		}
+
+		delete(previous, p.Time)
+
+		if changes := diffPoints(prev, p); len(changes) > 0 {
+			d.Changed = append(d.Changed, PointDiff{Time: p.Time, Changes: changes})
+		}
+	}
+
+	for _, p := range from.Data {
+		if _, ok := previous[p.Time]; ok {
+			d.Removed = append(d.Removed, p)
+		}
+	}
+
+	return d
+}
+
+func diffPoints(from, to DataPoint) []FieldChange {
+	var changes []FieldChange
+
+	vf, vt := reflect.ValueOf(from), reflect.ValueOf(to)
+
+	for _, f := range dataPointFields {
+		if f.name == "time" {
+			continue
+		}
+
+		hasFrom, hasTo := from.Has(f.name), to.Has(f.name)
+		before, after := vf.Field(f.index).Interface(), vt.Field(f.index).Interface()
+
+		if hasFrom == hasTo && before == after {
+			continue
+		}
+
+		c := FieldChange{Field: f.name}
+
+		if hasFrom {
+			c.Old = before
+		}
+
+		if hasTo {
+			c.New = after
+		}
+
+		if hasFrom && hasTo {
+			a, _ := from.Float(f.name)
+			b, _ := to.Float(f.name)
+			c.Delta = math.Round((b-a)*1e6) / 1e6
+		}
+
+		changes = append(changes, c)
+	}
+
+	return append(changes, diffExtras(from.Extras, to.Extras)...)
+}
+
+func diffExtras(from, to Extras) []FieldChange {
+	var names []string
+
+	for name := range from {
+		names = append(names, name)
+	}
+
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var changes []FieldChange
+
+	for _, name := range names {
+		before, hasFrom := from[name]
+		after, hasTo := to[name]
+
+		if hasFrom && hasTo && bytes.Equal(before, after) {
+			continue
+		}
+
+		c := FieldChange{Field: name}
+
+		if hasFrom {
+			c.Old = before
+		}
+
+		if hasTo {
+			c.New = after
+		}
+
+		a, okFrom := from.Float(name)
+		b, okTo := to.Float(name)
+
+		if okFrom && okTo {
+			c.Delta = math.Round((b-a)*1e6) / 1e6
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+func diffAlerts(from, to Alerts) AlertsDiff {
+	var d AlertsDiff
+
+	previous := make(map[string]Alert)
+
+	for _, a := range from {
+		previous[alertKey(a)] = a
+	}
+
+	for _, a := range to {
+		key := alertKey(a)
+		prev, ok := previous[key]
+
+		switch {
+		case !ok:
+			d.Added = append(d.Added, a)
+		case alertChanged(prev, a):
+			d.Updated = append(d.Updated, a)
+		}
+
+		delete(previous, key)
+	}
+
+	for _, a := range from {
+		if _, ok := previous[alertKey(a)]; ok {
+			d.Removed = append(d.Removed, a)
+		}
+	}
+
+	return d
+}
+
+// WriteText writes the diff as a report for humans, with times in the location of the newer
+// response.
+func (d *Diff) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+
+	if len(d.Currently) > 0 {
+		buf.WriteString("currently\n")
+		writeChanges(&buf, d.Currently)
+	}
+
+	d.writeBlock(&buf, "hourly", d.Hourly)
+	d.writeBlock(&buf, "daily", d.Daily)
+
+	for _, a := range []struct {
+		name   string
+		alerts Alerts
+	}{{"added", d.Alerts.Added}, {"removed", d.Alerts.Removed}, {"updated", d.Alerts.Updated}} {
+		for _, alert := range a.alerts {
+			fmt.Fprintf(&buf, "alert %s: %s (%s)", a.name, alert.Title, alert.Severity)
+
+			if alert.Expires != 0 {
+				fmt.Fprintf(&buf, " until %s", d.time(alert.Expires))
+			}
+
+			buf.WriteString("\n")
+		}
+	}
+
+	if buf.Len() == 0 {
+		buf.WriteString("no changes\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func (d *Diff) String() string {
+	var buf bytes.Buffer
+
+	d.WriteText(&buf)
+
+	return buf.String()
+}
+
+func (d *Diff) writeBlock(buf *bytes.Buffer, name string, b BlockDiff) {
+	for _, p := range b.Changed {
+		fmt.Fprintf(buf, "%s %s\n", name, d.time(p.Time))
+		writeChanges(buf, p.Changes)
+	}
+
+	for _, p := range b.Added {
+		fmt.Fprintf(buf, "%s %s added\n", name, d.time(p.Time))
+	}
+
+	for _, p := range b.Removed {
+		fmt.Fprintf(buf, "%s %s removed\n", name, d.time(p.Time))
+	}
+}
+
+func (d *Diff) time(ts int64) string {
+	return localTime(ts, d.loc).Format(time.RFC3339)
+}
+
+func writeChanges(buf *bytes.Buffer, changes []FieldChange) {
+	for _, c := range changes {
+		fmt.Fprintf(buf, "  %s: %s -> %s", c.Field, formatChangeValue(c.Old), formatChangeValue(c.New))
+
+		if c.Delta != 0 {
+			fmt.Fprintf(buf, " (%s)", formatDelta(c.Delta))
+		}
+
+		buf.WriteString("\n")
+	}
+}
+
+func formatChangeValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "missing"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.RawMessage:
+		return string(v)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func formatDelta(d float64) string {
+	s := strconv.FormatFloat(d, 'f', -1, 64)
+
+	if d > 0 {
+		return "+" + s
+	}
+
+	return s
+}
@@ -0,0 +1,104 @@
+package darksky
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func diffTestData(t *testing.T, stub string) (*APIData, *APIData) {
+	from, to := new(APIData), new(APIData)
+
+	if err := json.Unmarshal([]byte(forecastResponseStub), from); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal([]byte(stub), to); err != nil {
+		t.Fatal(err)
+	}
+
+	return from, to
+}
+
+func TestDiffDataEmpty(t *testing.T) {
+	from, to := diffTestData(t, forecastResponseStub)
+
+	d := DiffData(from, to)
+
+	if !d.Empty() {
+		t.Errorf("Same responses should have an empty diff, got %s", d)
+	}
+
+	assertString(t, "text", d.String(), "no changes\n")
+}
+
+func TestDiffData(t *testing.T) {
+	// The current ozone is missing from the newer response.
+	from, to := diffTestData(t, strings.Replace(forecastResponseStub, `5.72,
+        "ozone": 272.39`, "5.72", 1))
+
+	to.Currently.Temperature = 50.1
+	to.Currently.PrecipIntensity = 0.01
+	to.Currently.Extras.Set("smoke", 12.5)
+	to.Hourly.Data[0].Summary = "Clear"
+	to.Hourly.Data = append(to.Hourly.Data, DataPoint{Time: 1544378400, Temperature: 48})
+	to.Daily.Data = nil
+	to.Alerts[0].Severity = SeverityWarning
+	to.Alerts = append(to.Alerts, Alert{Title: "Flood Watch", Severity: SeverityWatch})
+
+	d := DiffData(from, to)
+
+	if d.Empty() {
+		t.Fatal("Diff should not be empty")
+	}
+
+	assertInt(t, "len(Currently)", int64(len(d.Currently)), 4)
+	assertString(t, "Currently[0].Field", d.Currently[0].Field, "ozone")
+
+	if d.Currently[0].New != nil {
+		t.Error("Missing fields should have a nil value")
+	}
+
+	assertString(t, "Currently[2].Field", d.Currently[2].Field, "temperature")
+	assertFloat(t, "Currently[2].Delta", d.Currently[2].Delta, 1.68)
+	assertString(t, "Currently[3].Field", d.Currently[3].Field, "smoke")
+	assertInt(t, "len(Hourly.Changed)", int64(len(d.Hourly.Changed)), 1)
+	assertInt(t, "Hourly.Changed[0].Time", d.Hourly.Changed[0].Time, 1544374800)
+	assertInt(t, "len(Hourly.Added)", int64(len(d.Hourly.Added)), 1)
+	assertInt(t, "len(Daily.Removed)", int64(len(d.Daily.Removed)), 1)
+	assertInt(t, "len(Alerts.Added)", int64(len(d.Alerts.Added)), 1)
+	assertInt(t, "len(Alerts.Updated)", int64(len(d.Alerts.Updated)), 1)
+
+	var buf bytes.Buffer
+
+	if err := d.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"currently\n",
+		"  temperature: 48.42 -> 50.1 (+1.68)\n",
+		"  ozone: 272.39 -> missing\n",
+		"  smoke: missing -> 12.5\n",
+		"hourly 2018-12-09T09:00:00-08:00\n  summary: Mostly Cloudy -> Clear\n",
+		"hourly 2018-12-09T10:00:00-08:00 added\n",
+		"daily 2018-12-09T00:00:00-08:00 removed\n",
+		"alert added: Flood Watch (watch)\n",
+		"alert updated: Alert title (warning) until 2018-12-09T08:00:00-08:00\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Report should contain %q, got:\n%s", line, buf.String())
+		}
+	}
+
+	content, err := json.Marshal(d)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), `{"field":"ozone","old":272.39,"new":null}`) {
+		t.Errorf("Unexpected JSON diff %s", content)
+	}
+}
@@ -0,0 +1,118 @@
+package darksky
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized matches, through errors.Is, an APIError caused by a missing or invalid secret.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrBadRequest matches, through errors.Is, an APIError caused by an invalid request, like
+	// coordinates out of bounds.
+	ErrBadRequest = errors.New("bad request")
+
+	// ErrQuotaExhausted matches, through errors.Is, an APIError caused by the usage limit of the
+	// secret being reached.
+	ErrQuotaExhausted = errors.New("quota exhausted")
+
+	// ErrServerError matches, through errors.Is, an APIError caused by a failure on the API side.
+	ErrServerError = errors.New("server error")
+)
+
+// APIError is returned when the API answers with an HTTP error status. Use errors.As to inspect
+// it, or errors.Is with one of ErrUnauthorized, ErrBadRequest, ErrQuotaExhausted or ErrServerError
+// to know what kind of error it is.
+type APIError struct {
+	// StatusCode of the HTTP response.
+	StatusCode int
+	// Message explaining the error, as given by the API when available.
+	Message string
+	// Header of the HTTP response.
+	Header http.Header
+	// Body of the HTTP response, uncompressed.
+	Body []byte
+}
+
+// apiError is the JSON payload of an error returned by the API.
+type apiError struct {
+	Code int    `json:"code"`
+	Err  string `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("HTTP %d Error - %s", e.StatusCode, e.Message)
+}
+
+// Is makes the APIError match the sentinel error corresponding to its status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden) &&
+			!e.isQuotaExhausted()
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrQuotaExhausted:
+		return e.isQuotaExhausted()
+	case ErrServerError:
+		return e.StatusCode >= 500
+	}
+
+	return false
+}
+
+// isQuotaExhausted is true on 429 responses, but also on the 403 responses the official API
+// returns once the daily usage limit is exceeded.
+func (e *APIError) isQuotaExhausted() bool {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	msg := strings.ToLower(e.Message)
+
+	return e.StatusCode == http.StatusForbidden && (strings.Contains(msg, "limit") || strings.Contains(msg, "quota"))
+}
+
+// HTTPError formats a txt error to inform it's an HTTP error and also include code.
+func HTTPError(code int, txt string) error {
+	return &APIError{
+		StatusCode: code,
+		Message:    txt,
+	}
+}
+
+// newAPIError extracts the error message from the response content according to its content type,
+// falling back to the status text when the content cannot be understood.
+func newAPIError(resp *http.Response, content []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       content,
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if err == nil {
+		switch {
+		case mediaType == "text/plain":
+			e.Message = strings.TrimSpace(string(content))
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			var data apiError
+
+			if err := json.Unmarshal(content, &data); err == nil {
+				e.Message = data.Err
+			}
+		}
+	}
+
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return e
+}
@@ -0,0 +1,90 @@
+package darksky
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorAs(t *testing.T) {
+	errClient := newErrorClient(403, `{"code":403,"error":"permission denied"}`, "application/json")
+	api, err := NewAPI("test-secret", HTTPClientOption(errClient))
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = api.Forecast(defaultLat, defaultLng)
+
+	var apiErr *APIError
+
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Error should be an *APIError, got %T", err)
+	}
+
+	assertInt(t, "StatusCode", int64(apiErr.StatusCode), 403)
+	assertString(t, "Message", apiErr.Message, "permission denied")
+	assertString(t, "Body", string(apiErr.Body), `{"code":403,"error":"permission denied"}`)
+	assertString(t, "Content-Type", apiErr.Header.Get("Content-Type"), "application/json")
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	responses := []struct {
+		code        int
+		body        string
+		contentType string
+		expected    string
+	}{
+		{403, "Forbidden\n", "text/plain; charset=utf-8", "Forbidden"},
+		{400, `{"code":400,"error":"The given location is invalid."}`, "application/json;charset=UTF-8", "The given location is invalid."},
+		{400, `{"code":400,"error":"The given location is invalid."}`, "application/problem+json", "The given location is invalid."},
+		{500, "not json", "application/json", "Internal Server Error"},
+		{502, "<html><body>Bad Gateway</body></html>", "text/html", "Bad Gateway"},
+		{503, "", "", "Service Unavailable"},
+	}
+
+	for _, r := range responses {
+		api, err := NewAPI("test-secret", HTTPClientOption(newErrorClient(r.code, r.body, r.contentType)))
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		_, err = api.Forecast(defaultLat, defaultLng)
+
+		var apiErr *APIError
+
+		if !errors.As(err, &apiErr) {
+			t.Errorf("Error should be an *APIError for %s, got %v", r.contentType, err)
+			continue
+		}
+
+		assertString(t, "Message", apiErr.Message, r.expected)
+		assertString(t, "Body", string(apiErr.Body), r.body)
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	errs := []struct {
+		err      error
+		expected error
+	}{
+		{HTTPError(http.StatusUnauthorized, "Unauthorized"), ErrUnauthorized},
+		{HTTPError(http.StatusForbidden, "permission denied"), ErrUnauthorized},
+		{HTTPError(http.StatusBadRequest, "The given location is invalid."), ErrBadRequest},
+		{HTTPError(http.StatusTooManyRequests, "Too Many Requests"), ErrQuotaExhausted},
+		{HTTPError(http.StatusForbidden, "daily usage limit exceeded"), ErrQuotaExhausted},
+		{HTTPError(http.StatusInternalServerError, "Internal Server Error"), ErrServerError},
+		{HTTPError(http.StatusServiceUnavailable, "Service Unavailable"), ErrServerError},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrBadRequest, ErrQuotaExhausted, ErrServerError}
+
+	for _, e := range errs {
+		for _, s := range sentinels {
+			if errors.Is(e.err, s) != (s == e.expected) {
+				t.Errorf("errors.Is(%q, %q) should be %t", e.err, s, s == e.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package darksky
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// ErrExtraNotFound occurs when decoding an extra property the object does not have.
+var ErrExtraNotFound = errors.New("extra property not found")
+
+var (
+	_, apiDataFieldIndex   = jsonFields(reflect.TypeOf(APIData{}))
+	_, dataBlockFieldIndex = jsonFields(reflect.TypeOf(DataBlock{}))
+	_, alertFieldIndex     = jsonFields(reflect.TypeOf(Alert{}))
+	_, flagsFieldIndex     = jsonFields(reflect.TypeOf(Flags{}))
+)
+
+// Extras holds the properties of an object unknown to this package, like those added by Dark
+// Sky compatible providers, as raw JSON by name. They are kept when encoding the object back.
+type Extras map[string]json.RawMessage
+
+// Has tells whether the extra property exists.
+func (e Extras) Has(name string) bool {
+	_, ok := e[name]
+
+	return ok
+}
+
+// Decode decodes the extra property into v.
+func (e Extras) Decode(name string, v interface{}) error {
+	raw, ok := e[name]
+
+	if !ok {
+		return ErrExtraNotFound
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// Float returns the extra property as a number, and whether it exists and is one.
+func (e Extras) Float(name string) (float64, bool) {
+	var v float64
+
+	return v, e.Decode(name, &v) == nil
+}
+
+// Int returns the extra property as an integer, and whether it exists and is one.
+func (e Extras) Int(name string) (int64, bool) {
+	var v int64
+
+	return v, e.Decode(name, &v) == nil
+}
+
+// Text returns the extra property as text, and whether it exists and is text.
+func (e Extras) Text(name string) (string, bool) {
+	var v string
+
+	return v, e.Decode(name, &v) == nil
+}
+
+// Bool returns the extra property as a boolean, and whether it exists and is one.
+func (e Extras) Bool(name string) (bool, bool) {
+	var v bool
+
+	return v, e.Decode(name, &v) == nil
+}
+
+// Set encodes v as the extra property, creating the map when needed.
+func (e *Extras) Set(name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	if *e == nil {
+		*e = make(Extras)
+	}
+
+	(*e)[name] = raw
+
+	return nil
+}
+
+// decodeExtras returns the properties of the JSON object not in known, nil when there is none.
+func decodeExtras(content []byte, known map[string]int) (Extras, error) {
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	return extras(raw, known), nil
+}
+
+func extras(raw map[string]json.RawMessage, known map[string]int) Extras {
+	var e Extras
+
+	for name, v := range raw {
+		if _, ok := known[name]; !ok {
+			if e == nil {
+				e = make(Extras)
+			}
+
+			e[name] = v
+		}
+	}
+
+	return e
+}
+
+// appendExtras adds the extra properties to the encoded JSON object, sorted by name. Those
+// named like a known property are left out.
+func appendExtras(content []byte, e Extras, known map[string]int) ([]byte, error) {
+	names := make([]string, 0, len(e))
+
+	for name := range e {
+		if _, ok := known[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return content, nil
+	}
+
+	sort.Strings(names)
+
+	buf := bytes.NewBuffer(content[:len(content)-1])
+
+	for _, name := range names {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(e[name])
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the block, keeping its unknown properties in Extras.
+func (b *DataBlock) UnmarshalJSON(content []byte) error {
+	type dataBlock DataBlock
+
+	e, err := decodeExtras(content, dataBlockFieldIndex)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(content, (*dataBlock)(b)); err != nil {
+		return err
+	}
+
+	b.Extras = e
+
+	return nil
+}
+
+// MarshalJSON encodes the block with its extra properties.
+func (b DataBlock) MarshalJSON() ([]byte, error) {
+	type dataBlock DataBlock
+
+	content, err := json.Marshal(dataBlock(b))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtras(content, b.Extras, dataBlockFieldIndex)
+}
+
+// UnmarshalJSON decodes the alert, keeping its unknown properties in Extras.
+func (a *Alert) UnmarshalJSON(content []byte) error {
+	type alert Alert
+
+	e, err := decodeExtras(content, alertFieldIndex)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(content, (*alert)(a)); err != nil {
+		return err
+	}
+
+	a.Extras = e
+
+	return nil
+}
+
+// MarshalJSON encodes the alert with its extra properties.
+func (a Alert) MarshalJSON() ([]byte, error) {
+	type alert Alert
+
+	content, err := json.Marshal(alert(a))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtras(content, a.Extras, alertFieldIndex)
+}
+
+// UnmarshalJSON decodes the flags, keeping their unknown properties in Extras.
+func (f *Flags) UnmarshalJSON(content []byte) error {
+	type flags Flags
+
+	e, err := decodeExtras(content, flagsFieldIndex)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(content, (*flags)(f)); err != nil {
+		return err
+	}
+
+	f.Extras = e
+
+	return nil
+}
+
+// MarshalJSON encodes the flags with their extra properties.
+func (f Flags) MarshalJSON() ([]byte, error) {
+	type flags Flags
+
+	content, err := json.Marshal(flags(f))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtras(content, f.Extras, flagsFieldIndex)
+}
@@ -0,0 +1,95 @@
+package darksky
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtras(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "pirateweather.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d APIData
+
+	if err := json.Unmarshal(content, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	elevation, ok := d.Extras.Int("elevation")
+
+	if !ok {
+		t.Error("Extra elevation should be an integer")
+	}
+
+	assertInt(t, "elevation", elevation, 70)
+
+	smoke, _ := d.Currently.Extras.Float("smoke")
+
+	assertFloat(t, "Currently.smoke", smoke, 41.38)
+
+	if _, ok := d.Currently.Extras.Text("smoke"); ok {
+		t.Error("Extra smoke should not be text")
+	}
+
+	liquid, _ := d.Daily.Data[0].Extras.Float("liquidAccumulation")
+
+	assertFloat(t, "Daily.Data[0].liquidAccumulation", liquid, 0.0255)
+
+	id, _ := d.Alerts[0].Extras.Text("id")
+
+	assertString(t, "Alerts[0].id", id, "urn:oid:2.49.0.1.124.1793546185.2023")
+
+	version, _ := d.Flags.Extras.Text("version")
+
+	assertString(t, "Flags.version", version, "V2.0.1")
+
+	var sourceTimes map[string]string
+
+	if err := d.Flags.Extras.Decode("sourceTimes", &sourceTimes); err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "Flags.sourceTimes.gfs", sourceTimes["gfs"], "2023-08-14 12Z")
+
+	if d.Hourly.Extras != nil || d.Currently.Extras.Has("temperature") {
+		t.Error("Known properties should not be extras")
+	}
+
+	if err := d.Flags.Extras.Decode("missing", &sourceTimes); !errors.Is(err, ErrExtraNotFound) {
+		t.Errorf("Expected ErrExtraNotFound, got %v", err)
+	}
+}
+
+func TestExtrasSet(t *testing.T) {
+	b := DataBlock{Summary: "Clear"}
+
+	if err := b.Extras.Set("source", "model"); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Extras["summary"] = json.RawMessage(`"ignored"`)
+
+	content, err := json.Marshal(b)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "json", string(content), `{"data":null,"summary":"Clear","source":"model"}`)
+
+	var dp DataPoint
+
+	if err := dp.Extras.Set("smoke", 12.5); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = json.Marshal(dp)
+
+	assertString(t, "json", string(content), `{"time":0,"smoke":12.5}`)
+}
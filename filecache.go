@@ -0,0 +1,232 @@
+package darksky
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	fileCacheExt  = ".gz"
+	fileCacheLock = ".lock"
+)
+
+// FileCache keeps responses in a directory, one gzip-compressed file per entry, so they survive
+// restarts. Entries are written to a temporary file renamed in place while holding the lock of
+// the directory, making the cache safe for concurrent use by several goroutines and processes.
+type FileCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// fileCacheHeader is the first line of an entry file, followed by the response content.
+type fileCacheHeader struct {
+	Key     string      `json:"key"`
+	Expires time.Time   `json:"expires"`
+	Header  http.Header `json:"header"`
+}
+
+// NewFileCache creates a cache storing its entries in dir, created if needed. Entries live for
+// ttl, or when ttl is 0, for the lifetime given by the Cache-Control or Expires headers of the
+// response.
+func NewFileCache(dir string, ttl time.Duration) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileCache{dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+// Get returns the entry stored under key, if still fresh.
+func (c *FileCache) Get(key string) (*CacheEntry, error) {
+	path := c.path(key)
+
+	unlock, err := c.lock(false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	entry, err := readFileCacheEntry(path, key)
+
+	if os.IsNotExist(err) {
+		return nil, ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.now().Before(entry.Expires) {
+		return nil, ErrCacheMiss
+	}
+
+	return entry, nil
+}
+
+// Set stores the entry under key, unless it is already expired.
+func (c *FileCache) Set(key string, entry *CacheEntry) error {
+	entry, ok := withTTL(entry, c.ttl, c.now())
+
+	if !ok {
+		return nil
+	}
+
+	path := c.path(key)
+
+	unlock, err := c.lock(true)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return writeFileCacheEntry(path, key, entry)
+}
+
+// Prune removes the expired entries from the directory.
+func (c *FileCache) Prune() error {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*"+fileCacheExt))
+
+	if err != nil {
+		return err
+	}
+
+	now := c.now()
+
+	for _, path := range paths {
+		if err := c.pruneEntry(path, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *FileCache) pruneEntry(path string, now time.Time) error {
+	unlock, err := c.lock(true)
+
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	entry, err := readFileCacheEntry(path, "")
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err == nil && now.Before(entry.Expires) {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// lock takes the lock of the directory, shared for readers or exclusive for writers. A single
+// lock file is used, so the directory does not gain a lock file for every key looked up.
+func (c *FileCache) lock(exclusive bool) (func(), error) {
+	return lockFile(filepath.Join(c.dir, fileCacheLock), exclusive)
+}
+
+// path of the entry file, named after a hash of the key since keys are not valid file names.
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+fileCacheExt)
+}
+
+// readFileCacheEntry reads the entry stored at path, checking it belongs to key unless key is
+// empty, a hash collision being treated as a missing entry.
+func readFileCacheEntry(path, key string) (*CacheEntry, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer gr.Close()
+
+	br := bufio.NewReader(gr)
+	line, err := br.ReadBytes('\n')
+
+	if err != nil {
+		return nil, err
+	}
+
+	var h fileCacheHeader
+
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, err
+	}
+
+	if key != "" && h.Key != key {
+		return nil, os.ErrNotExist
+	}
+
+	content, err := ioutil.ReadAll(br)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &CacheEntry{
+		Content: content,
+		Header:  h.Header,
+		Expires: h.Expires,
+	}, nil
+}
+
+func writeFileCacheEntry(path, key string, entry *CacheEntry) error {
+	line, err := json.Marshal(fileCacheHeader{
+		Key:     key,
+		Expires: entry.Expires,
+		Header:  entry.Header,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+
+	for _, p := range [][]byte{line, []byte("\n"), entry.Content} {
+		if _, err := gw.Write(p); err != nil {
+			return err
+		}
+	}
+
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, buf.Bytes())
+}
@@ -0,0 +1,219 @@
+package darksky
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestFileCache(t *testing.T, ttl time.Duration) (*FileCache, func()) {
+	dir, err := ioutil.TempDir("", "darksky-cache")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewFileCache(filepath.Join(dir, "entries"), ttl)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, func() { os.RemoveAll(dir) }
+}
+
+func TestFileCacheRoundTrip(t *testing.T) {
+	c, cleanup := newTestFileCache(t, 0)
+	defer cleanup()
+
+	entry := &CacheEntry{
+		Content: []byte(forecastResponseStub),
+		Header:  http.Header{"X-Forecast-Api-Calls": []string{"12"}},
+		Expires: time.Now().Add(time.Hour).Round(0),
+	}
+
+	if err := c.Set("key", entry); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.Get("key")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "Content", string(got.Content), forecastResponseStub)
+	assertString(t, "Header", got.Header.Get("X-Forecast-API-Calls"), "12")
+
+	if !got.Expires.Equal(entry.Expires) {
+		t.Errorf("Expires should be %s, got %s", entry.Expires, got.Expires)
+	}
+
+	f, err := os.Open(c.path("key"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if _, err := gzip.NewReader(f); err != nil {
+		t.Errorf("Entry should be stored gzip-compressed: %s", err)
+	}
+
+	if _, err := c.Get("other"); err != ErrCacheMiss {
+		t.Errorf("Unknown key should return ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestFileCacheExpiry(t *testing.T) {
+	c, cleanup := newTestFileCache(t, time.Minute)
+	defer cleanup()
+
+	clock := &fakeClock{time.Now()}
+	c.now = clock.now
+
+	if err := c.Set("key", &CacheEntry{Content: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get("key"); err != nil {
+		t.Errorf("Entry should be fresh with the configured TTL, got %v", err)
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+
+	if _, err := c.Get("key"); err != ErrCacheMiss {
+		t.Errorf("Entry should have expired, got %v", err)
+	}
+
+	if err := c.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(c.path("key")); !os.IsNotExist(err) {
+		t.Error("Expired entry should have been pruned")
+	}
+}
+
+func TestFileCacheSurvivesRestart(t *testing.T) {
+	c, cleanup := newTestFileCache(t, 0)
+	defer cleanup()
+
+	client := newCacheControlClient("max-age=600")
+	api, err := NewAPI("test-secret", HTTPClientOption(client), CacheOption(c))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewFileCache(c.dir, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api, err = NewAPI("test-secret", HTTPClientOption(client), CacheOption(restarted))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateForecast(t, d)
+	assertInt(t, "requests", int64(client.requests), 1)
+
+	if !d.Metadata.Cached {
+		t.Error("Response should come from the cache")
+	}
+
+	assertString(t, "Cache-Control", d.Metadata.CacheControl, "max-age=600")
+}
+
+func TestFileCacheConcurrentAccess(t *testing.T) {
+	c, cleanup := newTestFileCache(t, time.Hour)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := c.Set("key", &CacheEntry{Content: []byte(forecastResponseStub)}); err != nil {
+				t.Error(err)
+			}
+
+			entry, err := c.Get("key")
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if string(entry.Content) != forecastResponseStub {
+				t.Error("Entry content should never be partially written")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	tmp, err := filepath.Glob(filepath.Join(c.dir, ".*.tmp*"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tmp) != 0 {
+		t.Errorf("Temporary files should have been renamed, found %v", tmp)
+	}
+}
+
+func TestFileCacheLockFiles(t *testing.T) {
+	c, cleanup := newTestFileCache(t, time.Hour)
+	defer cleanup()
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.Get(fmt.Sprintf("missing-%d", i)); err != ErrCacheMiss {
+			t.Fatalf("Expected ErrCacheMiss, got %v", err)
+		}
+	}
+
+	if err := c.Set("key", &CacheEntry{Content: []byte("{}"), Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(c.dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+
+	if len(names) != 2 {
+		t.Errorf("The directory should hold the lock and the entry, got %v", names)
+	}
+}
@@ -0,0 +1,93 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package darksky
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lockRetryDelay = 10 * time.Millisecond
+
+	// A lock file without owner older than this is considered left behind by a process that died
+	// before writing its PID.
+	staleLockAge = time.Minute
+)
+
+// lockFile takes an exclusive lock by creating the file at path, holding the PID of the owner,
+// waiting while another goroutine or process holds it. A lock whose owner is no longer running
+// is broken. Readers are not distinguished from writers on these platforms. The lock is released
+// by the returned function.
+func lockFile(path string, exclusive bool) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+
+			if err != nil {
+				os.Remove(path)
+
+				return nil, err
+			}
+
+			return func() { os.Remove(path) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if owner, stale := staleLock(path); stale {
+			breakLock(path, owner)
+			continue
+		}
+
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// staleLock tells whether the owner of the lock at path is no longer running, returning the
+// content of the lock.
+func staleLock(path string) (string, bool) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return "", false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+
+	if err != nil {
+		// The owner may not have written its PID yet.
+		fi, err := os.Stat(path)
+
+		return string(content), err == nil && time.Since(fi.ModTime()) > staleLockAge
+	}
+
+	return string(content), !processAlive(pid)
+}
+
+// breakLock removes the stale lock at path, unless another waiter broke it first and a new
+// owner took it. The lock is moved aside before being checked, and moved back if it was taken.
+func breakLock(path, owner string) {
+	aside := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+
+	if err := os.Rename(path, aside); err != nil {
+		return
+	}
+
+	if content, err := ioutil.ReadFile(aside); err == nil && string(content) != owner {
+		os.Rename(aside, path)
+		return
+	}
+
+	os.Remove(aside)
+}
@@ -0,0 +1,94 @@
+package darksky
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// flightGroup coalesces identical requests made concurrently, so only one of them reaches the
+// HTTP client while the others wait for its result.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	// done is closed once the result is available.
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+	resp    *flightResponse
+	err     error
+}
+
+// flightResponse is what is shared between the callers of a flight. It must not be modified.
+type flightResponse struct {
+	statusCode int
+	header     http.Header
+	content    []byte
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// do calls fn once for all the concurrent callers using the same key. fn runs with its own
+// context, cancelled only once every caller gave up waiting, so a caller cancelling does not
+// fail the others.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (*flightResponse, error)) (*flightResponse, error) {
+	g.mu.Lock()
+
+	f, ok := g.flights[key]
+
+	if !ok {
+		fctx, cancel := context.WithCancel(context.Background())
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+
+		go g.run(fctx, key, f, fn)
+	}
+
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		g.leave(key, f)
+
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(context.Context) (*flightResponse, error)) {
+	f.resp, f.err = fn(ctx)
+
+	g.mu.Lock()
+
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+
+	g.mu.Unlock()
+
+	f.cancel()
+	close(f.done)
+}
+
+// leave removes a caller from the flight, cancelling it when nobody waits for it anymore.
+func (g *flightGroup) leave(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f.waiters--
+
+	if f.waiters == 0 {
+		f.cancel()
+
+		if g.flights[key] == f {
+			delete(g.flights, key)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package darksky
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTPClientGateMock holds every request until the gate is opened, counting them.
+type HTTPClientGateMock struct {
+	mu       sync.Mutex
+	requests int
+	gate     context.Context
+	open     context.CancelFunc
+}
+
+func newGateClient() *HTTPClientGateMock {
+	gate, open := context.WithCancel(context.Background())
+
+	return &HTTPClientGateMock{gate: gate, open: open}
+}
+
+func (c *HTTPClientGateMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests++
+	c.mu.Unlock()
+
+	select {
+	case <-c.gate.Done():
+		return ClientMock.Do(req)
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// waitForWaiters blocks until n callers wait on the single flight of the API.
+func waitForWaiters(t *testing.T, api *API, n int) {
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		api.flights.mu.Lock()
+
+		waiters := 0
+
+		for _, f := range api.flights.flights {
+			waiters += f.waiters
+		}
+
+		api.flights.mu.Unlock()
+
+		if waiters == n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("%d callers should have joined the flight", n)
+}
+
+func TestCoalesceIdenticalRequests(t *testing.T) {
+	client := newGateClient()
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 10
+
+	results := make([]*APIData, callers)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			d, err := api.Forecast(defaultLat, defaultLng)
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			results[i] = d
+		}(i)
+	}
+
+	waitForWaiters(t, api, callers)
+	client.open()
+	wg.Wait()
+
+	assertInt(t, "requests", int64(client.requests), 1)
+
+	results[0].Currently.Summary = "Modified"
+	results[0].Hourly.Data[0].Temperature = -1
+	results[0].Metadata.Header.Set("X-Modified", "true")
+
+	for _, d := range results[1:] {
+		validateForecast(t, d)
+
+		if d.Metadata.Header.Get("X-Modified") != "" {
+			t.Error("Metadata header should not be shared between callers")
+		}
+	}
+}
+
+func TestCoalesceDifferentRequests(t *testing.T) {
+	client := newGateClient()
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.open()
+
+	var wg sync.WaitGroup
+
+	for _, opt := range []Option{UnitOption(UnitSI), UnitOption(UnitUS)} {
+		wg.Add(1)
+
+		go func(opt Option) {
+			defer wg.Done()
+
+			if _, err := api.Forecast(defaultLat, defaultLng, opt); err != nil {
+				t.Error(err)
+			}
+		}(opt)
+	}
+
+	wg.Wait()
+
+	assertInt(t, "requests", int64(client.requests), 2)
+}
+
+func TestCoalesceCallerCancelled(t *testing.T) {
+	client := newGateClient()
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+
+	go func() {
+		_, err := api.ForecastContext(ctx, defaultLat, defaultLng)
+		cancelled <- err
+	}()
+
+	waitForWaiters(t, api, 1)
+
+	var d *APIData
+
+	done := make(chan error)
+
+	go func() {
+		var err error
+		d, err = api.Forecast(defaultLat, defaultLng)
+		done <- err
+	}()
+
+	waitForWaiters(t, api, 2)
+	cancel()
+
+	if err := <-cancelled; err != context.Canceled {
+		t.Errorf("Cancelled caller should get context.Canceled, got %v", err)
+	}
+
+	client.open()
+
+	if err := <-done; err != nil {
+		t.Errorf("Other caller should not be affected by the cancellation, got %v", err)
+	}
+
+	validateForecast(t, d)
+	assertInt(t, "requests", int64(client.requests), 1)
+}
+
+func TestCoalesceAllCallersCancelled(t *testing.T) {
+	client := newGateClient()
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := api.ForecastContext(ctx, defaultLat, defaultLng); err != context.DeadlineExceeded {
+		t.Errorf("Should have return context.DeadlineExceeded, got %v", err)
+	}
+
+	client.open()
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Errorf("Abandoned flight should not be reused, got %v", err)
+	}
+
+	assertInt(t, "requests", int64(client.requests), 2)
+}
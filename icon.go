@@ -0,0 +1,232 @@
+package darksky
+
+import "strings"
+
+// Icon is a machine-readable text summary of a data point or a data block, suitable for selecting
+// an icon for display. Values other than the documented ones may be returned in the future, so
+// an unknown icon should be handled like IconCloudy or a default.
+type Icon string
+
+const (
+	// IconClearDay clear sky during the day.
+	IconClearDay Icon = "clear-day"
+	// IconClearNight clear sky during the night.
+	IconClearNight Icon = "clear-night"
+	// IconRain rain.
+	IconRain Icon = "rain"
+	// IconSnow snow.
+	IconSnow Icon = "snow"
+	// IconSleet sleet.
+	IconSleet Icon = "sleet"
+	// IconWind wind.
+	IconWind Icon = "wind"
+	// IconFog fog.
+	IconFog Icon = "fog"
+	// IconCloudy cloudy sky.
+	IconCloudy Icon = "cloudy"
+	// IconPartlyCloudyDay partly cloudy sky during the day.
+	IconPartlyCloudyDay Icon = "partly-cloudy-day"
+	// IconPartlyCloudyNight partly cloudy sky during the night.
+	IconPartlyCloudyNight Icon = "partly-cloudy-night"
+	// IconHail hail, reserved by the API for future use.
+	IconHail Icon = "hail"
+	// IconThunderstorm thunderstorm, reserved by the API for future use.
+	IconThunderstorm Icon = "thunderstorm"
+	// IconTornado tornado, reserved by the API for future use.
+	IconTornado Icon = "tornado"
+)
+
+// PrecipType is the type of precipitation occurring. Values other than the documented ones may
+// be returned by compatible providers.
+type PrecipType string
+
+const (
+	// PrecipRain rain, also used for freezing rain, drizzle and light rain.
+	PrecipRain PrecipType = "rain"
+	// PrecipSnow snow.
+	PrecipSnow PrecipType = "snow"
+	// PrecipSleet sleet, also used for ice pellets and wintery mix.
+	PrecipSleet PrecipType = "sleet"
+)
+
+// Index of a condition in the descriptions.
+const (
+	condClear = iota
+	condPartlyCloudy
+	condCloudy
+	condRain
+	condSnow
+	condSleet
+	condWind
+	condFog
+	condHail
+	condThunderstorm
+	condTornado
+)
+
+// iconInfo is what is known of a documented icon. wmo is a present weather code of the WMO code
+// table 4677, -1 when there is none.
+type iconInfo struct {
+	cond  int
+	wmo   int
+	emoji string
+}
+
+var icons = map[Icon]iconInfo{
+	IconClearDay:          {condClear, 0, "☀️"},
+	IconClearNight:        {condClear, 0, "🌙"},
+	IconRain:              {condRain, 63, "🌧️"},
+	IconSnow:              {condSnow, 73, "🌨️"},
+	IconSleet:             {condSleet, 79, "🌨️"},
+	IconWind:              {condWind, -1, "💨"},
+	IconFog:               {condFog, 45, "🌫️"},
+	IconCloudy:            {condCloudy, 3, "☁️"},
+	IconPartlyCloudyDay:   {condPartlyCloudy, 2, "⛅"},
+	IconPartlyCloudyNight: {condPartlyCloudy, 2, "☁️"},
+	IconHail:              {condHail, 90, "🧊"},
+	IconThunderstorm:      {condThunderstorm, 95, "⛈️"},
+	IconTornado:           {condTornado, 19, "🌪️"},
+}
+
+var precipTypes = map[PrecipType]iconInfo{
+	PrecipRain:  {condRain, 63, "🌧️"},
+	PrecipSnow:  {condSnow, 73, "❄️"},
+	PrecipSleet: {condSleet, 79, "🌨️"},
+}
+
+// ParseIcon returns the icon of a text summary, and whether it is a documented one. Unknown
+// values are kept as they are.
+func ParseIcon(s string) (Icon, bool) {
+	i := Icon(strings.ToLower(strings.TrimSpace(s)))
+
+	return i, i.Known()
+}
+
+// Known tells whether the icon is a documented one.
+func (i Icon) Known() bool {
+	_, ok := icons[i]
+
+	return ok
+}
+
+// WMOCode returns the present weather code of the WMO code table 4677 closest to the icon, and
+// whether there is one.
+func (i Icon) WMOCode() (int, bool) {
+	info, ok := icons[i]
+
+	return info.wmo, ok && info.wmo >= 0
+}
+
+// Emoji returns a symbol for the icon, empty when it is unknown.
+func (i Icon) Emoji() string {
+	return icons[i].emoji
+}
+
+// Description returns a short description of the icon in a language of the API, like LangFR,
+// defaulting to English. Unknown icons are described by their value.
+func (i Icon) Description(lang string) string {
+	info, ok := icons[i]
+
+	if !ok {
+		return string(i)
+	}
+
+	return description(info.cond, lang)
+}
+
+// ParsePrecipType returns the precipitation type of a text, and whether it is a documented one.
+// Unknown values are kept as they are.
+func ParsePrecipType(s string) (PrecipType, bool) {
+	p := PrecipType(strings.ToLower(strings.TrimSpace(s)))
+
+	return p, p.Known()
+}
+
+// Known tells whether the precipitation type is a documented one.
+func (p PrecipType) Known() bool {
+	_, ok := precipTypes[p]
+
+	return ok
+}
+
+// WMOCode returns the present weather code of the WMO code table 4677 for a moderate
+// precipitation of the type, and whether there is one.
+func (p PrecipType) WMOCode() (int, bool) {
+	info, ok := precipTypes[p]
+
+	return info.wmo, ok
+}
+
+// Emoji returns a symbol for the precipitation type, empty when it is unknown.
+func (p PrecipType) Emoji() string {
+	return precipTypes[p].emoji
+}
+
+// Description returns a short description of the precipitation type in a language of the API,
+// like LangFR, defaulting to English. Unknown types are described by their value.
+func (p PrecipType) Description(lang string) string {
+	info, ok := precipTypes[p]
+
+	if !ok {
+		return string(p)
+	}
+
+	return description(info.cond, lang)
+}
+
+func description(cond int, lang string) string {
+	d, ok := descriptions[strings.ToLower(lang)]
+
+	if !ok {
+		d = descriptions[LangEN]
+	}
+
+	return d[cond]
+}
+
+// descriptions of the conditions, by language, in the order of the cond constants.
+var descriptions = map[string][11]string{
+	LangAR:   {"صافٍ", "غائم جزئياً", "غائم", "مطر", "ثلج", "مطر متجمد", "رياح", "ضباب", "بَرَد", "عاصفة رعدية", "إعصار"},
+	LangAZ:   {"Aydın", "Qismən buludlu", "Buludlu", "Yağış", "Qar", "Sulu qar", "Küləkli", "Duman", "Dolu", "Şimşəkli tufan", "Tornado"},
+	LangBE:   {"Ясна", "Пераменная воблачнасць", "Воблачна", "Дождж", "Снег", "Мокры снег", "Ветрана", "Туман", "Град", "Навальніца", "Тарнада"},
+	LangBG:   {"Ясно", "Разкъсана облачност", "Облачно", "Дъжд", "Сняг", "Суграшица", "Ветровито", "Мъгла", "Градушка", "Гръмотевична буря", "Торнадо"},
+	LangBS:   {"Vedro", "Djelimično oblačno", "Oblačno", "Kiša", "Snijeg", "Susnježica", "Vjetrovito", "Magla", "Grad", "Grmljavina", "Tornado"},
+	LangCA:   {"Serè", "Parcialment ennuvolat", "Ennuvolat", "Pluja", "Neu", "Aiguaneu", "Ventós", "Boira", "Calamarsa", "Tempesta", "Tornado"},
+	LangCS:   {"Jasno", "Polojasno", "Zataženo", "Déšť", "Sníh", "Déšť se sněhem", "Větrno", "Mlha", "Kroupy", "Bouřka", "Tornádo"},
+	LangDA:   {"Klart", "Delvist skyet", "Overskyet", "Regn", "Sne", "Slud", "Blæsende", "Tåge", "Hagl", "Tordenvejr", "Tornado"},
+	LangDE:   {"Klar", "Teilweise bewölkt", "Bewölkt", "Regen", "Schnee", "Schneeregen", "Windig", "Nebel", "Hagel", "Gewitter", "Tornado"},
+	LangEL:   {"Αίθριος", "Μερικώς νεφελώδης", "Νεφελώδης", "Βροχή", "Χιόνι", "Χιονόνερο", "Ανεμώδης", "Ομίχλη", "Χαλάζι", "Καταιγίδα", "Ανεμοστρόβιλος"},
+	LangEN:   {"Clear", "Partly cloudy", "Cloudy", "Rain", "Snow", "Sleet", "Windy", "Fog", "Hail", "Thunderstorm", "Tornado"},
+	LangES:   {"Despejado", "Parcialmente nublado", "Nublado", "Lluvia", "Nieve", "Aguanieve", "Ventoso", "Niebla", "Granizo", "Tormenta", "Tornado"},
+	LangET:   {"Selge", "Vahelduv pilvisus", "Pilvine", "Vihm", "Lumi", "Lörts", "Tuuline", "Udu", "Rahe", "Äike", "Tornaado"},
+	LangFI:   {"Selkeää", "Puolipilvistä", "Pilvistä", "Sadetta", "Lunta", "Räntää", "Tuulista", "Sumua", "Raekuuroja", "Ukkosta", "Tornado"},
+	LangFR:   {"Dégagé", "Partiellement nuageux", "Nuageux", "Pluie", "Neige", "Grésil", "Venteux", "Brouillard", "Grêle", "Orage", "Tornade"},
+	LangHE:   {"בהיר", "מעונן חלקית", "מעונן", "גשם", "שלג", "גשם מעורב בשלג", "סוער", "ערפל", "ברד", "סופת רעמים", "טורנדו"},
+	LangHR:   {"Vedro", "Djelomično oblačno", "Oblačno", "Kiša", "Snijeg", "Susnježica", "Vjetrovito", "Magla", "Tuča", "Grmljavinsko nevrijeme", "Tornado"},
+	LangHU:   {"Derült", "Részben felhős", "Felhős", "Eső", "Havazás", "Havas eső", "Szeles", "Köd", "Jégeső", "Zivatar", "Tornádó"},
+	LangID:   {"Cerah", "Berawan sebagian", "Berawan", "Hujan", "Salju", "Hujan bercampur salju", "Berangin", "Kabut", "Hujan es", "Badai petir", "Tornado"},
+	LangIS:   {"Heiðskírt", "Hálfskýjað", "Skýjað", "Rigning", "Snjókoma", "Slydda", "Hvasst", "Þoka", "Hagl", "Þrumuveður", "Skýstrokkur"},
+	LangIT:   {"Sereno", "Parzialmente nuvoloso", "Nuvoloso", "Pioggia", "Neve", "Nevischio", "Ventoso", "Nebbia", "Grandine", "Temporale", "Tornado"},
+	LangJA:   {"晴れ", "一部曇り", "曇り", "雨", "雪", "みぞれ", "強風", "霧", "ひょう", "雷雨", "竜巻"},
+	LangKA:   {"მოწმენდილი", "ნაწილობრივ მოღრუბლული", "მოღრუბლული", "წვიმა", "თოვლი", "თოვლჭყაპი", "ქარიანი", "ნისლი", "სეტყვა", "ჭექა-ქუხილი", "ტორნადო"},
+	LangKO:   {"맑음", "구름 조금", "흐림", "비", "눈", "진눈깨비", "바람", "안개", "우박", "뇌우", "토네이도"},
+	LangKW:   {"Kler", "Kommolek yn rann", "Kommolek", "Glaw", "Ergh", "Erghlaw", "Gwynsek", "Niwl", "Keser", "Taran", "Korwyns"},
+	LangLV:   {"Skaidrs", "Daļēji mākoņains", "Mākoņains", "Lietus", "Sniegs", "Slapjš sniegs", "Vējains", "Migla", "Krusa", "Pērkona negaiss", "Viesuļvētra"},
+	LangNB:   {"Klarvær", "Delvis skyet", "Skyet", "Regn", "Snø", "Sludd", "Vind", "Tåke", "Hagl", "Tordenvær", "Tornado"},
+	LangNL:   {"Helder", "Half bewolkt", "Bewolkt", "Regen", "Sneeuw", "Natte sneeuw", "Winderig", "Mist", "Hagel", "Onweer", "Tornado"},
+	LangNO:   {"Klarvær", "Delvis skyet", "Skyet", "Regn", "Snø", "Sludd", "Vind", "Tåke", "Hagl", "Tordenvær", "Tornado"},
+	LangPL:   {"Bezchmurnie", "Częściowe zachmurzenie", "Pochmurno", "Deszcz", "Śnieg", "Deszcz ze śniegiem", "Wietrznie", "Mgła", "Grad", "Burza", "Tornado"},
+	LangPT:   {"Céu limpo", "Parcialmente nublado", "Nublado", "Chuva", "Neve", "Água-neve", "Ventoso", "Nevoeiro", "Granizo", "Trovoada", "Tornado"},
+	LangRO:   {"Senin", "Parțial noros", "Noros", "Ploaie", "Ninsoare", "Lapoviță", "Vânt", "Ceață", "Grindină", "Furtună", "Tornadă"},
+	LangRU:   {"Ясно", "Переменная облачность", "Облачно", "Дождь", "Снег", "Мокрый снег", "Ветрено", "Туман", "Град", "Гроза", "Торнадо"},
+	LangSK:   {"Jasno", "Polojasno", "Zamračené", "Dážď", "Sneh", "Dážď so snehom", "Veterno", "Hmla", "Krúpy", "Búrka", "Tornádo"},
+	LangSL:   {"Jasno", "Delno oblačno", "Oblačno", "Dež", "Sneg", "Dež s snegom", "Vetrovno", "Megla", "Toča", "Nevihta", "Tornado"},
+	LangSR:   {"Vedro", "Delimično oblačno", "Oblačno", "Kiša", "Sneg", "Susnežica", "Vetrovito", "Magla", "Grad", "Grmljavina", "Tornado"},
+	LangSV:   {"Klart", "Delvis molnigt", "Molnigt", "Regn", "Snö", "Snöblandat regn", "Blåsigt", "Dimma", "Hagel", "Åska", "Tornado"},
+	LangTE:   {"Lalehan moos", "Kalohan balun", "Kalohan", "Udan", "Neve", "Udan ho neve", "Anin", "Abuabu", "Udan-fatuk", "Rai-tarutu", "Tornadu"},
+	LangTR:   {"Açık", "Parçalı bulutlu", "Bulutlu", "Yağmur", "Kar", "Karla karışık yağmur", "Rüzgarlı", "Sis", "Dolu", "Gök gürültülü fırtına", "Hortum"},
+	LangUK:   {"Ясно", "Мінлива хмарність", "Хмарно", "Дощ", "Сніг", "Мокрий сніг", "Вітряно", "Туман", "Град", "Гроза", "Торнадо"},
+	LangXPIG: {"Earclay", "Artlypay oudyclay", "Oudyclay", "Ainray", "Owsnay", "Eetslay", "Indyway", "Ogfay", "Ailhay", "Understormthay", "Ornadotay"},
+	LangZH:   {"晴", "局部多云", "阴", "雨", "雪", "雨夹雪", "大风", "雾", "冰雹", "雷暴", "龙卷风"},
+	LangZHTW: {"晴", "局部多雲", "陰", "雨", "雪", "雨夾雪", "強風", "霧", "冰雹", "雷暴", "龍捲風"},
+}
@@ -0,0 +1,95 @@
+package darksky
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseIcon(t *testing.T) {
+	i, ok := ParseIcon(" Partly-Cloudy-Day ")
+
+	if !ok || i != IconPartlyCloudyDay {
+		t.Errorf("Expected %s, got %s", IconPartlyCloudyDay, i)
+	}
+
+	i, ok = ParseIcon("smoke")
+
+	if ok {
+		t.Error("Icon smoke should be unknown")
+	}
+
+	assertString(t, "unknown icon", string(i), "smoke")
+	assertString(t, "unknown icon description", i.Description(LangFR), "smoke")
+	assertString(t, "unknown icon emoji", i.Emoji(), "")
+
+	if _, ok := i.WMOCode(); ok {
+		t.Error("Unknown icons should have no WMO code")
+	}
+}
+
+func TestIconTables(t *testing.T) {
+	code, ok := IconFog.WMOCode()
+
+	if !ok {
+		t.Error("Fog should have a WMO code")
+	}
+
+	assertInt(t, "fog WMO code", int64(code), 45)
+
+	if _, ok := IconWind.WMOCode(); ok {
+		t.Error("Wind should have no WMO code")
+	}
+
+	assertString(t, "rain emoji", IconRain.Emoji(), "🌧️")
+	assertString(t, "snow fr", IconSnow.Description(LangFR), "Neige")
+	assertString(t, "cloudy unknown language", IconCloudy.Description("xx"), "Cloudy")
+	assertString(t, "sleet de", PrecipSleet.Description("DE"), "Schneeregen")
+
+	for _, lang := range supportedLanguages {
+		d, ok := descriptions[lang]
+
+		if !ok {
+			t.Errorf("Missing descriptions for language %s", lang)
+			continue
+		}
+
+		for i, s := range d {
+			if s == "" {
+				t.Errorf("Missing description %d for language %s", i, lang)
+			}
+		}
+	}
+
+	for i := range icons {
+		if i.Emoji() == "" {
+			t.Errorf("Missing emoji for icon %s", i)
+		}
+	}
+}
+
+func TestParsePrecipType(t *testing.T) {
+	p, ok := ParsePrecipType("SNOW")
+
+	if !ok || p != PrecipSnow {
+		t.Errorf("Expected %s, got %s", PrecipSnow, p)
+	}
+
+	code, _ := p.WMOCode()
+
+	assertInt(t, "snow WMO code", int64(code), 73)
+
+	if _, ok := ParsePrecipType("none"); ok {
+		t.Error("Precipitation type none should be unknown")
+	}
+}
+
+func TestDecodeUnknownIcon(t *testing.T) {
+	var dp DataPoint
+
+	if err := json.Unmarshal([]byte(`{"time":1,"icon":"smoke","precipType":"none"}`), &dp); err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "icon", string(dp.Icon), "smoke")
+	assertString(t, "precipType", string(dp.PrecipType), "none")
+}
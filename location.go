@@ -0,0 +1,186 @@
+package darksky
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Location of the requested coordinates, from the IANA timezone name, or when it is unknown to
+// the system, from the offset. Defaults to UTC when none is given.
+func (d *APIData) Location() *time.Location {
+	if d.Timezone != "" {
+		if loc, err := time.LoadLocation(d.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	if d.Offset == 0 {
+		return time.UTC
+	}
+
+	seconds := int(d.Offset * 3600)
+	sign := '+'
+
+	if seconds < 0 {
+		sign = '-'
+	}
+
+	abs := seconds
+
+	if abs < 0 {
+		abs = -abs
+	}
+
+	return time.FixedZone(fmt.Sprintf("UTC%c%02d:%02d", sign, abs/3600, abs%3600/60), seconds)
+}
+
+// UnmarshalJSON decodes the data, keeping its unknown properties in Extras, then gives the
+// location to every data point and alert so their time accessors return local times.
+func (d *APIData) UnmarshalJSON(b []byte) error {
+	type apiData APIData
+
+	e, err := decodeExtras(b, apiDataFieldIndex)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, (*apiData)(d)); err != nil {
+		return err
+	}
+
+	d.Extras = e
+	d.Localize()
+
+	return nil
+}
+
+// Localize gives the location and the unit system of the data to every data point, and the
+// location to every alert. It is done when decoding, and only needs to be called again after
+// changing Timezone, Offset or Flags.Units, or building data by hand.
+func (d *APIData) Localize() {
+	loc := d.Location()
+
+	d.Currently.loc = loc
+	d.Currently.units = d.Flags.Units
+
+	for _, b := range []*DataBlock{&d.Minutely, &d.Hourly, &d.Daily} {
+		b.loc = loc
+
+		for i := range b.Data {
+			b.Data[i].loc = loc
+			b.Data[i].units = d.Flags.Units
+		}
+	}
+
+	for i := range d.Alerts {
+		d.Alerts[i].loc = loc
+	}
+}
+
+// localTime converts a unix timestamp to loc, 0 being the zero time.
+func localTime(ts int64, loc *time.Location) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return time.Unix(ts, 0).In(loc)
+}
+
+// Location of the block, UTC unless localized.
+func (b DataBlock) Location() *time.Location {
+	if b.loc == nil {
+		return time.UTC
+	}
+
+	return b.loc
+}
+
+// Location of the data point, UTC unless localized.
+func (dp DataPoint) Location() *time.Location {
+	if dp.loc == nil {
+		return time.UTC
+	}
+
+	return dp.loc
+}
+
+// LocalTime is Time in the location of the data point.
+func (dp DataPoint) LocalTime() time.Time { return localTime(dp.Time, dp.loc) }
+
+// LocalSunriseTime is SunriseTime in the location of the data point.
+func (dp DataPoint) LocalSunriseTime() time.Time { return localTime(dp.SunriseTime, dp.loc) }
+
+// LocalSunsetTime is SunsetTime in the location of the data point.
+func (dp DataPoint) LocalSunsetTime() time.Time { return localTime(dp.SunsetTime, dp.loc) }
+
+// LocalTemperatureHighTime is TemperatureHighTime in the location of the data point.
+func (dp DataPoint) LocalTemperatureHighTime() time.Time {
+	return localTime(dp.TemperatureHighTime, dp.loc)
+}
+
+// LocalTemperatureLowTime is TemperatureLowTime in the location of the data point.
+func (dp DataPoint) LocalTemperatureLowTime() time.Time {
+	return localTime(dp.TemperatureLowTime, dp.loc)
+}
+
+// LocalApparentTemperatureHighTime is ApparentTemperatureHighTime in the location of the data point.
+func (dp DataPoint) LocalApparentTemperatureHighTime() time.Time {
+	return localTime(dp.ApparentTemperatureHighTime, dp.loc)
+}
+
+// LocalApparentTemperatureLowTime is ApparentTemperatureLowTime in the location of the data point.
+func (dp DataPoint) LocalApparentTemperatureLowTime() time.Time {
+	return localTime(dp.ApparentTemperatureLowTime, dp.loc)
+}
+
+// LocalTemperatureMinTime is TemperatureMinTime in the location of the data point.
+func (dp DataPoint) LocalTemperatureMinTime() time.Time {
+	return localTime(dp.TemperatureMinTime, dp.loc)
+}
+
+// LocalTemperatureMaxTime is TemperatureMaxTime in the location of the data point.
+func (dp DataPoint) LocalTemperatureMaxTime() time.Time {
+	return localTime(dp.TemperatureMaxTime, dp.loc)
+}
+
+// LocalApparentTemperatureMinTime is ApparentTemperatureMinTime in the location of the data point.
+func (dp DataPoint) LocalApparentTemperatureMinTime() time.Time {
+	return localTime(dp.ApparentTemperatureMinTime, dp.loc)
+}
+
+// LocalApparentTemperatureMaxTime is ApparentTemperatureMaxTime in the location of the data point.
+func (dp DataPoint) LocalApparentTemperatureMaxTime() time.Time {
+	return localTime(dp.ApparentTemperatureMaxTime, dp.loc)
+}
+
+// LocalPrecipIntensityMaxTime is PrecipIntensityMaxTime in the location of the data point.
+func (dp DataPoint) LocalPrecipIntensityMaxTime() time.Time {
+	return localTime(dp.PrecipIntensityMaxTime, dp.loc)
+}
+
+// LocalUvIndexTime is UvIndexTime in the location of the data point.
+func (dp DataPoint) LocalUvIndexTime() time.Time { return localTime(dp.UvIndexTime, dp.loc) }
+
+// LocalWindGustTime is WindGustTime in the location of the data point.
+func (dp DataPoint) LocalWindGustTime() time.Time { return localTime(dp.WindGustTime, dp.loc) }
+
+// Location of the alert, UTC unless localized.
+func (a Alert) Location() *time.Location {
+	if a.loc == nil {
+		return time.UTC
+	}
+
+	return a.loc
+}
+
+// LocalTime is Time in the location of the alert.
+func (a Alert) LocalTime() time.Time { return localTime(a.Time, a.loc) }
+
+// LocalExpires is Expires in the location of the alert.
+func (a Alert) LocalExpires() time.Time { return localTime(a.Expires, a.loc) }
@@ -0,0 +1,77 @@
+package darksky
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLocalTimes(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "Location", d.Location().String(), "America/Los_Angeles")
+	assertString(t, "Hourly.Location", d.Hourly.Location().String(), "America/Los_Angeles")
+	assertString(t, "Currently.LocalTime", d.Currently.LocalTime().Format(time.RFC3339), "2018-12-09T09:57:36-08:00")
+	assertString(t, "Daily.Data[0].LocalSunriseTime", d.Daily.Data[0].LocalSunriseTime().Format(time.RFC3339), "2018-12-09T07:15:17-08:00")
+	assertString(t, "Daily.Data[0].LocalTemperatureHighTime", d.Daily.Data[0].LocalTemperatureHighTime().Format(time.RFC3339), "2018-12-09T16:00:00-08:00")
+	assertString(t, "Daily.Data[0].LocalTemperatureMinTime", d.Daily.Data[0].LocalTemperatureMinTime().Format(time.RFC3339), "2018-12-09T08:00:00-08:00")
+	assertString(t, "Alerts[0].LocalExpires", d.Alerts[0].LocalExpires().Format(time.RFC3339), "2018-12-09T08:00:00-08:00")
+
+	if !d.Minutely.Data[0].LocalTemperatureLowTime().IsZero() {
+		t.Error("Missing times should be zero")
+	}
+}
+
+func TestLocalTimesDaylightSaving(t *testing.T) {
+	var d APIData
+
+	// Daily points around the start of daylight saving time in Los Angeles, 2019-03-10.
+	payload := `{"timezone":"America/Los_Angeles","offset":-8,"daily":{"data":[{"time":1552118400},{"time":1552204800},{"time":1552287600}]}}`
+
+	if err := json.Unmarshal([]byte(payload), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []string{"2019-03-09T00:00:00-08:00", "2019-03-10T00:00:00-08:00", "2019-03-11T00:00:00-07:00"} {
+		assertString(t, "LocalTime", d.Daily.Data[i].LocalTime().Format(time.RFC3339), expected)
+	}
+}
+
+func TestLocationOffsetFallback(t *testing.T) {
+	locations := []struct {
+		data     APIData
+		expected string
+	}{
+		{APIData{Timezone: "Unknown/Zone", Offset: -3.5}, "2019-03-01T08:30:00-03:30"},
+		{APIData{Offset: 5.75}, "2019-03-01T17:45:00+05:45"},
+		{APIData{}, "2019-03-01T12:00:00Z"},
+	}
+
+	ts := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC).Unix()
+
+	for _, l := range locations {
+		l.data.Currently.Time = ts
+		l.data.Alerts = []Alert{{Time: ts}}
+		l.data.Localize()
+
+		assertString(t, "Currently.LocalTime", l.data.Currently.LocalTime().Format(time.RFC3339), l.expected)
+		assertString(t, "Alerts[0].LocalTime", l.data.Alerts[0].LocalTime().Format(time.RFC3339), l.expected)
+	}
+}
+
+func TestLocalTimesNotLocalized(t *testing.T) {
+	dp := DataPoint{Time: 1551441600}
+
+	assertString(t, "LocalTime", dp.LocalTime().Format(time.RFC3339), "2019-03-01T12:00:00Z")
+	assertString(t, "Location", dp.Location().String(), "UTC")
+}
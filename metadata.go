@@ -0,0 +1,110 @@
+package darksky
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiCallsHeader     = "X-Forecast-API-Calls"
+	responseTimeHeader = "X-Response-Time"
+)
+
+// ResponseMetadata holds what the HTTP response tells about a query, beside its payload.
+type ResponseMetadata struct {
+	// StatusCode of the HTTP response.
+	StatusCode int
+	// Header of the HTTP response.
+	Header http.Header
+	// APICalls made with the secret so far today, from the X-Forecast-API-Calls header,
+	// 0 when absent.
+	APICalls int
+	// ResponseTime spent by the server on the query, from the X-Response-Time header,
+	// 0 when absent.
+	ResponseTime time.Duration
+	// CacheControl header of the response.
+	CacheControl string
+	// Date the response was generated at, zero when absent.
+	Date time.Time
+	// Expires is the date after which the response is considered stale, zero when absent.
+	Expires time.Time
+	// Cached is true when the response was served from the cache instead of the network.
+	Cached bool
+}
+
+func newResponseMetadata(resp *http.Response) *ResponseMetadata {
+	m := &ResponseMetadata{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		CacheControl: resp.Header.Get("Cache-Control"),
+	}
+
+	if calls, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get(apiCallsHeader))); err == nil {
+		m.APICalls = calls
+	}
+
+	m.ResponseTime = parseResponseTime(resp.Header.Get(responseTimeHeader))
+
+	if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		m.Date = t
+	}
+
+	if t, err := http.ParseTime(resp.Header.Get("Expires")); err == nil {
+		m.Expires = t
+	}
+
+	return m
+}
+
+// parseResponseTime reads durations like "42.3ms", or a bare number of milliseconds.
+func parseResponseTime(v string) time.Duration {
+	v = strings.TrimSpace(v)
+
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+
+	if ms, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	return 0
+}
+
+// TTL is the freshness lifetime of the response, taken from the max-age directive of the
+// Cache-Control header, or else from the difference between Expires and Date. It is 0 when the
+// response must not be cached or when the server gave no lifetime.
+func (m *ResponseMetadata) TTL() time.Duration {
+	for _, directive := range strings.Split(m.CacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			if s, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && s > 0 {
+				return time.Duration(s) * time.Second
+			}
+
+			return 0
+		}
+	}
+
+	if m.Expires.IsZero() {
+		return 0
+	}
+
+	date := m.Date
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	if ttl := m.Expires.Sub(date); ttl > 0 {
+		return ttl
+	}
+
+	return 0
+}
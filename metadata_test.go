@@ -0,0 +1,110 @@
+package darksky
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseMetadata(t *testing.T) {
+	client := HTTPClientFuncMock(func(req *http.Request) (*http.Response, error) {
+		resp, err := ClientMock.Do(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Header.Set("X-Forecast-API-Calls", "42")
+		resp.Header.Set("X-Response-Time", "88.5ms")
+		resp.Header.Set("Cache-Control", "max-age=3600")
+		resp.Header.Set("Date", "Mon, 06 Feb 1978 19:00:00 GMT")
+		resp.Header.Set("Expires", "Mon, 06 Feb 1978 20:00:00 GMT")
+
+		return resp, nil
+	})
+
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := d.Metadata
+
+	if m == nil {
+		t.Fatal("Metadata should have been set")
+	}
+
+	assertInt(t, "StatusCode", int64(m.StatusCode), 200)
+	assertInt(t, "APICalls", int64(m.APICalls), 42)
+	assertInt(t, "ResponseTime", int64(m.ResponseTime), int64(88500*time.Microsecond))
+	assertString(t, "CacheControl", m.CacheControl, "max-age=3600")
+	assertString(t, "Header", m.Header.Get("Content-Encoding"), "gzip")
+	assertInt(t, "Date", m.Date.Unix(), 255639600)
+	assertInt(t, "Expires", m.Expires.Unix(), 255643200)
+	assertInt(t, "TTL", int64(m.TTL()), int64(time.Hour))
+}
+
+func TestResponseMetadataMissingHeaders(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(ClientMock))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := api.TimeMachine(defaultLat, defaultLng, time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := d.Metadata
+
+	assertInt(t, "APICalls", int64(m.APICalls), 0)
+	assertInt(t, "ResponseTime", int64(m.ResponseTime), 0)
+	assertInt(t, "TTL", int64(m.TTL()), 0)
+
+	if !m.Date.IsZero() || !m.Expires.IsZero() {
+		t.Error("Date and Expires should be zero when absent")
+	}
+}
+
+func TestParseResponseTime(t *testing.T) {
+	times := map[string]time.Duration{
+		"120ms":  120 * time.Millisecond,
+		" 1.5s ": 1500 * time.Millisecond,
+		"12.25":  12250 * time.Microsecond,
+		"":       0,
+		"fast":   0,
+	}
+
+	for v, expected := range times {
+		assertInt(t, "ResponseTime "+v, int64(parseResponseTime(v)), int64(expected))
+	}
+}
+
+func TestResponseMetadataTTL(t *testing.T) {
+	date := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	metas := []struct {
+		meta     ResponseMetadata
+		expected time.Duration
+	}{
+		{ResponseMetadata{CacheControl: "public, max-age=300"}, 5 * time.Minute},
+		{ResponseMetadata{CacheControl: "no-cache", Date: date, Expires: date.Add(time.Hour)}, 0},
+		{ResponseMetadata{CacheControl: "max-age=0"}, 0},
+		{ResponseMetadata{Date: date, Expires: date.Add(10 * time.Minute)}, 10 * time.Minute},
+		{ResponseMetadata{Date: date, Expires: date.Add(-time.Minute)}, 0},
+		{ResponseMetadata{}, 0},
+	}
+
+	for _, m := range metas {
+		assertInt(t, "TTL", int64(m.meta.TTL()), int64(m.expected))
+	}
+}
@@ -0,0 +1,248 @@
+package darksky
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// jsonField describes a field of a struct encoded to JSON.
+type jsonField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// dataPointFields are the JSON fields of DataPoint, in declaration order. Their position is their
+// bit in the presence bitmap.
+var dataPointFields, dataPointFieldIndex = jsonFields(reflect.TypeOf(DataPoint{}))
+
+func jsonFields(t reflect.Type) ([]jsonField, map[string]int) {
+	var fields []jsonField
+
+	index := make(map[string]int)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+
+		if f.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		index[parts[0]] = len(fields)
+		fields = append(fields, jsonField{
+			index:     i,
+			name:      parts[0],
+			omitEmpty: len(parts) > 1 && parts[1] == "omitempty",
+		})
+	}
+
+	if len(fields) > 64 {
+		panic("darksky: too many fields for the presence bitmap of " + t.Name())
+	}
+
+	return fields, index
+}
+
+// UnmarshalJSON decodes the data point, remembering which fields were present, even with a zero
+// value, so they are not confused with missing ones.
+func (dp *DataPoint) UnmarshalJSON(b []byte) error {
+	type dataPoint DataPoint
+
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*dp = DataPoint{}
+
+	if err := json.Unmarshal(b, (*dataPoint)(dp)); err != nil {
+		return err
+	}
+
+	for name, v := range raw {
+		if i, ok := dataPointFieldIndex[name]; ok && string(v) != "null" {
+			dp.present |= 1 << uint(i)
+		}
+	}
+
+	dp.Extras = extras(raw, dataPointFieldIndex)
+
+	return nil
+}
+
+// MarshalJSON encodes the data point with the fields that were present when decoded, or marked
+// present, those with a non-zero value and the extra properties.
+func (dp DataPoint) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	v := reflect.ValueOf(dp)
+
+	buf.WriteByte('{')
+
+	for i, f := range dataPointFields {
+		fv := v.Field(f.index)
+
+		if f.omitEmpty && !dp.isPresent(i) && isZero(fv) {
+			continue
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		content, err := json.Marshal(fv.Interface())
+
+		if err != nil {
+			return nil, err
+		}
+
+		name, _ := json.Marshal(f.name)
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(content)
+	}
+
+	buf.WriteByte('}')
+
+	return appendExtras(buf.Bytes(), dp.Extras, dataPointFieldIndex)
+}
+
+func (dp DataPoint) isPresent(i int) bool {
+	return dp.present&(1<<uint(i)) != 0
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// Has tells whether the field, named as in the JSON payload like "temperature", was present when
+// decoded, was marked present, or has a non-zero value.
+func (dp DataPoint) Has(field string) bool {
+	i, ok := dataPointFieldIndex[field]
+
+	if !ok {
+		return false
+	}
+
+	return dp.isPresent(i) || !isZero(reflect.ValueOf(dp).Field(dataPointFields[i].index))
+}
+
+// MarkPresent marks fields, named as in the JSON payload, as present so they are encoded even
+// with a zero value. Unknown names are ignored.
+func (dp *DataPoint) MarkPresent(fields ...string) {
+	for _, f := range fields {
+		if i, ok := dataPointFieldIndex[f]; ok {
+			dp.present |= 1 << uint(i)
+		}
+	}
+}
+
+// Fields returns the names of the fields the data point has, in the order of the struct.
+func (dp DataPoint) Fields() []string {
+	var names []string
+
+	for _, f := range dataPointFields {
+		if dp.Has(f.name) {
+			names = append(names, f.name)
+		}
+	}
+
+	return names
+}
+
+// Float returns the value of a numeric field, named as in the JSON payload, and whether the data
+// point has it.
+func (dp DataPoint) Float(field string) (float64, bool) {
+	fv, ok := dp.field(field)
+
+	if !ok {
+		return 0, false
+	}
+
+	switch fv.Kind() {
+	case reflect.Float64:
+		return fv.Float(), true
+	case reflect.Int64:
+		return float64(fv.Int()), true
+	}
+
+	return 0, false
+}
+
+// Int returns the value of an integer field, like a time, named as in the JSON payload, and
+// whether the data point has it.
+func (dp DataPoint) Int(field string) (int64, bool) {
+	fv, ok := dp.field(field)
+
+	if !ok || fv.Kind() != reflect.Int64 {
+		return 0, false
+	}
+
+	return fv.Int(), true
+}
+
+// Text returns the value of a text field, named as in the JSON payload, and whether the data
+// point has it.
+func (dp DataPoint) Text(field string) (string, bool) {
+	fv, ok := dp.field(field)
+
+	if !ok || fv.Kind() != reflect.String {
+		return "", false
+	}
+
+	return fv.String(), true
+}
+
+func (dp DataPoint) field(name string) (reflect.Value, bool) {
+	if !dp.Has(name) {
+		return reflect.Value{}, false
+	}
+
+	return reflect.ValueOf(dp).Field(dataPointFields[dataPointFieldIndex[name]].index), true
+}
+
+// MarshalJSON encodes the data with its extra properties, leaving out the data point, blocks and
+// flags missing from the response rather than encoding their zero value.
+func (d APIData) MarshalJSON() ([]byte, error) {
+	type apiData APIData
+
+	v := struct {
+		apiData
+		Currently *DataPoint `json:"currently,omitempty"`
+		Minutely  *DataBlock `json:"minutely,omitempty"`
+		Hourly    *DataBlock `json:"hourly,omitempty"`
+		Daily     *DataBlock `json:"daily,omitempty"`
+		Flags     *Flags     `json:"flags,omitempty"`
+	}{apiData: apiData(d)}
+
+	if len(d.Currently.Fields()) > 0 || len(d.Currently.Extras) > 0 {
+		v.Currently = &d.Currently
+	}
+
+	for _, b := range []struct {
+		src *DataBlock
+		dst **DataBlock
+	}{{&d.Minutely, &v.Minutely}, {&d.Hourly, &v.Hourly}, {&d.Daily, &v.Daily}} {
+		if b.src.Data != nil || b.src.Summary != "" || b.src.Icon != "" || len(b.src.Extras) > 0 {
+			*b.dst = b.src
+		}
+	}
+
+	if !isZero(reflect.ValueOf(d.Flags)) {
+		v.Flags = &d.Flags
+	}
+
+	content, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return appendExtras(content, d.Extras, apiDataFieldIndex)
+}
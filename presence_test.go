@@ -0,0 +1,114 @@
+package darksky
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDataPointPresence(t *testing.T) {
+	var dp DataPoint
+
+	payload := `{"time":1544378256,"summary":"Clear","temperature":0,"windBearing":0,"precipProbability":0.2}`
+
+	if err := json.Unmarshal([]byte(payload), &dp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"time", "summary", "temperature", "windBearing", "precipProbability"} {
+		if !dp.Has(f) {
+			t.Errorf("Field %s should be present", f)
+		}
+	}
+
+	for _, f := range []string{"humidity", "uvIndex", "icon", "unknown"} {
+		if dp.Has(f) {
+			t.Errorf("Field %s should be missing", f)
+		}
+	}
+
+	temperature, ok := dp.Float("temperature")
+
+	if !ok {
+		t.Error("Float should return present zero values")
+	}
+
+	assertFloat(t, "temperature", temperature, 0)
+
+	if _, ok := dp.Float("humidity"); ok {
+		t.Error("Float should not return missing values")
+	}
+
+	tm, ok := dp.Int("time")
+
+	if !ok {
+		t.Error("Int should return the time")
+	}
+
+	assertInt(t, "time", tm, 1544378256)
+
+	if _, ok := dp.Int("temperature"); ok {
+		t.Error("Int should not return float fields")
+	}
+
+	summary, _ := dp.Text("summary")
+
+	assertString(t, "summary", summary, "Clear")
+
+	fields := dp.Fields()
+
+	assertInt(t, "len(fields)", int64(len(fields)), 5)
+}
+
+func TestDataPointRoundTrip(t *testing.T) {
+	var dp DataPoint
+
+	payload := `{"precipProbability":0,"temperature":0,"time":1544378256,"windBearing":0}`
+
+	if err := json.Unmarshal([]byte(payload), &dp); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := json.Marshal(dp)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "json", string(content), payload)
+
+	dp.Humidity = 0.5
+
+	content, _ = json.Marshal(dp)
+
+	assertString(t, "json", string(content), `{"humidity":0.5,"precipProbability":0,"temperature":0,"time":1544378256,"windBearing":0}`)
+}
+
+func TestDataPointMarkPresent(t *testing.T) {
+	dp := DataPoint{Time: 1544378256}
+
+	content, _ := json.Marshal(dp)
+
+	assertString(t, "json", string(content), `{"time":1544378256}`)
+
+	dp.MarkPresent("uvIndex")
+
+	if !dp.Has("uvIndex") {
+		t.Error("Field uvIndex should be present")
+	}
+
+	content, _ = json.Marshal(dp)
+
+	assertString(t, "json", string(content), `{"time":1544378256,"uvIndex":0}`)
+}
+
+func TestDataPointNull(t *testing.T) {
+	var dp DataPoint
+
+	if err := json.Unmarshal([]byte(`{"time":1,"temperature":null}`), &dp); err != nil {
+		t.Fatal(err)
+	}
+
+	if dp.Has("temperature") {
+		t.Error("Null fields should be missing")
+	}
+}
@@ -0,0 +1,7 @@
+package darksky
+
+// processAlive tells whether the process with the PID is running. Plan 9 has no way to check it
+// without signalling the process, so it is assumed to be, and only locks without owner are broken.
+func processAlive(pid int) bool {
+	return true
+}
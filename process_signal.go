@@ -0,0 +1,26 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !plan9 && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!plan9,!windows
+
+package darksky
+
+import (
+	"os"
+	"syscall"
+)
+
+// processAlive tells whether the process with the PID is running, by sending it the null signal.
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+
+	p, err := os.FindProcess(pid)
+
+	if err != nil {
+		return false
+	}
+
+	defer p.Release()
+
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,21 @@
+package darksky
+
+import "os"
+
+// processAlive tells whether the process with the PID is running. FindProcess opens the process,
+// which fails once it is gone.
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+
+	p, err := os.FindProcess(pid)
+
+	if err != nil {
+		return false
+	}
+
+	p.Release()
+
+	return true
+}
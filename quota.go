@@ -0,0 +1,273 @@
+package darksky
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+var (
+	// ErrBudgetExhausted occurs when a query is made once the hard budget of the Quota is reached
+	// for the current period. The query is not sent.
+	ErrBudgetExhausted = errors.New("budget of API calls exhausted for the current period")
+
+	// ErrInvalidQuota occurs when creating a quota with a non positive hard budget, a soft
+	// threshold above it or a reset time outside of a day.
+	ErrInvalidQuota = errors.New("quota configuration provided is invalid")
+
+	// ErrNilQuota occurs when passing a nil quota to the QuotaOption.
+	ErrNilQuota = errors.New("quota provided cannot be nil")
+)
+
+// QuotaConfig describes the budget of API calls allowed per day.
+type QuotaConfig struct {
+	// Hard is the number of calls allowed per period, queries beyond fail with ErrBudgetExhausted.
+	Hard int
+	// Soft is the number of calls after which a warning is logged, 0 to disable it.
+	Soft int
+	// ResetAt is the time of day, in UTC, at which the count goes back to 0. Defaults to midnight.
+	ResetAt time.Duration
+	// Store persists the count so it survives restarts, nil to keep it in memory only.
+	Store QuotaStore
+}
+
+// QuotaState is the count of calls made during a period, as persisted by a QuotaStore.
+type QuotaState struct {
+	Calls  int       `json:"calls"`
+	Period time.Time `json:"period"`
+}
+
+// QuotaStore persists the state of a Quota.
+type QuotaStore interface {
+	Load() (QuotaState, error)
+	Save(QuotaState) error
+}
+
+// Quota tracks the API calls made against a daily budget. It is safe for concurrent use and can
+// be shared by several APIs using the same secret.
+type Quota struct {
+	mu     sync.Mutex
+	config QuotaConfig
+	state  QuotaState
+	warned bool
+	logger *log.Logger
+	now    func() time.Time
+}
+
+// NewQuota creates a Quota, restoring its count from the store if any.
+func NewQuota(c QuotaConfig) (*Quota, error) {
+	if c.Hard <= 0 || c.Soft < 0 || c.Soft > c.Hard || c.ResetAt < 0 || c.ResetAt >= day {
+		return nil, ErrInvalidQuota
+	}
+
+	q := &Quota{config: c, now: time.Now}
+
+	if c.Store != nil {
+		s, err := c.Store.Load()
+
+		if err != nil {
+			return nil, err
+		}
+
+		q.state = s
+	}
+
+	return q, nil
+}
+
+// QuotaOption to count the calls made by the API and reject queries once the budget is spent.
+// Warnings are reported through the logger of the API.
+func QuotaOption(q *Quota) APIOption {
+	return func(api *API) error {
+		if q == nil {
+			return ErrNilQuota
+		}
+
+		api.quota = q
+
+		return nil
+	}
+}
+
+// Calls made during the current period.
+func (q *Quota) Calls() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+
+	return q.state.Calls
+}
+
+// Remaining calls for the current period.
+func (q *Quota) Remaining() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+
+	if r := q.config.Hard - q.state.Calls; r > 0 {
+		return r
+	}
+
+	return 0
+}
+
+// ResetTime is when the current period ends.
+func (q *Quota) ResetTime() time.Time {
+	return q.periodStart(q.now()).Add(day)
+}
+
+// reserve counts a call about to be made, or fails when the budget is exhausted.
+func (q *Quota) reserve() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+
+	if q.state.Calls >= q.config.Hard {
+		return ErrBudgetExhausted
+	}
+
+	q.state.Calls++
+	q.checkSoft()
+	q.save()
+
+	return nil
+}
+
+// observe aligns the count with the one reported by the server, which also knows about calls
+// made by other processes using the same secret.
+func (q *Quota) observe(m *ResponseMetadata) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.roll()
+
+	if m.APICalls > q.state.Calls {
+		q.state.Calls = m.APICalls
+		q.checkSoft()
+		q.save()
+	}
+}
+
+// roll starts a new period when the current one is over.
+func (q *Quota) roll() {
+	if start := q.periodStart(q.now()); !start.Equal(q.state.Period) {
+		q.state = QuotaState{Period: start}
+		q.warned = false
+		q.save()
+	}
+}
+
+func (q *Quota) periodStart(now time.Time) time.Time {
+	return now.UTC().Add(-q.config.ResetAt).Truncate(day).Add(q.config.ResetAt)
+}
+
+func (q *Quota) checkSoft() {
+	if q.config.Soft > 0 && q.state.Calls >= q.config.Soft && !q.warned {
+		q.warned = true
+		q.logf("API calls soft limit reached: %d of %d calls used until %s",
+			q.state.Calls, q.config.Hard, q.state.Period.Add(day).Format(time.RFC3339))
+	}
+}
+
+func (q *Quota) save() {
+	if q.config.Store == nil {
+		return
+	}
+
+	if err := q.config.Store.Save(q.state); err != nil {
+		q.logf("saving quota state: %s", err)
+	}
+}
+
+// setDefaultLogger gives the quota a logger unless it has one. The quota may already be used by
+// another API, so it is done under the lock.
+func (q *Quota) setDefaultLogger(l *log.Logger) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.logger == nil {
+		q.logger = l
+	}
+}
+
+// logf logs through the logger of the quota, the lock must be held.
+func (q *Quota) logf(format string, v ...interface{}) {
+	if q.logger != nil {
+		q.logger.Printf(format, v...)
+	}
+}
+
+// FileQuotaStore persists a QuotaState as a JSON file.
+type FileQuotaStore struct {
+	Path string
+}
+
+// NewFileQuotaStore creates a store persisting the quota state in the file at path.
+func NewFileQuotaStore(path string) *FileQuotaStore {
+	return &FileQuotaStore{Path: path}
+}
+
+// Load reads the state from the file, a missing file being an empty state.
+func (s *FileQuotaStore) Load() (QuotaState, error) {
+	var state QuotaState
+
+	content, err := ioutil.ReadFile(s.Path)
+
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(content, &state)
+
+	return state, err
+}
+
+// Save writes the state to a temporary file renamed over the previous one, so a crash never
+// leaves a truncated file behind.
+func (s *FileQuotaStore) Save(state QuotaState) error {
+	content, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, content)
+}
+
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(content)
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,265 @@
+package darksky
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTPClientCountingMock counts the requests reaching it, reporting calls in the
+// X-Forecast-API-Calls header and cacheControl in the Cache-Control header when set.
+type HTTPClientCountingMock struct {
+	requests     int
+	calls        int
+	cacheControl string
+}
+
+func (c *HTTPClientCountingMock) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+
+	resp, err := ClientMock.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if c.calls > 0 {
+		resp.Header.Set("X-Forecast-API-Calls", strconv.Itoa(c.calls))
+	}
+
+	if c.cacheControl != "" {
+		resp.Header.Set("Cache-Control", c.cacheControl)
+	}
+
+	return resp, nil
+}
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestQuota(t *testing.T, c QuotaConfig, clock *fakeClock) *Quota {
+	q, err := NewQuota(c)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.now = clock.now
+
+	return q
+}
+
+func TestQuotaHardLimit(t *testing.T) {
+	clock := &fakeClock{time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+	client := &HTTPClientCountingMock{}
+	q := newTestQuota(t, QuotaConfig{Hard: 2}, clock)
+
+	api, err := NewAPI("test-secret", HTTPClientOption(client), QuotaOption(q))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := api.TimeMachine(defaultLat, defaultLng, time.Now()); err != ErrBudgetExhausted {
+		t.Errorf("Should have return ErrBudgetExhausted, got %v", err)
+	}
+
+	assertInt(t, "requests", int64(client.requests), 2)
+	assertInt(t, "Remaining", int64(q.Remaining()), 0)
+
+	clock.t = clock.t.Add(14 * time.Hour)
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Errorf("Budget should have been reset on the next day, got %v", err)
+	}
+
+	assertInt(t, "Calls", int64(q.Calls()), 1)
+}
+
+func TestQuotaResetAt(t *testing.T) {
+	clock := &fakeClock{time.Date(2019, 3, 1, 5, 59, 0, 0, time.UTC)}
+	q := newTestQuota(t, QuotaConfig{Hard: 10, ResetAt: 6 * time.Hour}, clock)
+
+	if err := q.reserve(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "ResetTime", q.ResetTime().Unix(), time.Date(2019, 3, 1, 6, 0, 0, 0, time.UTC).Unix())
+
+	clock.t = clock.t.Add(2 * time.Minute)
+
+	assertInt(t, "Calls", int64(q.Calls()), 0)
+	assertInt(t, "ResetTime", q.ResetTime().Unix(), time.Date(2019, 3, 2, 6, 0, 0, 0, time.UTC).Unix())
+}
+
+func TestQuotaSoftLimitWarning(t *testing.T) {
+	w := &logWriter{}
+	logger := log.New(w, "darksky test - ", log.LstdFlags)
+	clock := &fakeClock{time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+	q := newTestQuota(t, QuotaConfig{Hard: 5, Soft: 2}, clock)
+
+	api, err := NewAPI("test-secret", HTTPClientOption(&HTTPClientCountingMock{}), QuotaOption(q), LoggerOption(logger))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(w.res) != 0 {
+		t.Errorf("Nothing should have been logged below the soft limit, got %s", w.res)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(w.res), "soft limit reached: 2 of 5") {
+		t.Errorf("Soft limit warning should have been logged, got %q", w.res)
+	}
+}
+
+func TestQuotaSeededFromHeader(t *testing.T) {
+	clock := &fakeClock{time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+	q := newTestQuota(t, QuotaConfig{Hard: 8}, clock)
+
+	api, err := NewAPI("test-secret", HTTPClientOption(&HTTPClientCountingMock{calls: 7}), QuotaOption(q))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "Calls", int64(q.Calls()), 7)
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != ErrBudgetExhausted {
+		t.Errorf("Should have return ErrBudgetExhausted, got %v", err)
+	}
+}
+
+func TestQuotaSharedBetweenAPIs(t *testing.T) {
+	// Saving into a missing directory fails, so every call made through the quota logs.
+	store := NewFileQuotaStore(filepath.Join(os.TempDir(), "darksky-missing", "quota.json"))
+	q, err := NewQuota(QuotaConfig{Hard: 1000, Store: store})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 10; i++ {
+			if err := q.reserve(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	logger := log.New(ioutil.Discard, "", 0)
+
+	for i := 0; i < 10; i++ {
+		if _, err := NewAPI("test-secret", QuotaOption(q), LoggerOption(logger)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	wg.Wait()
+
+	assertInt(t, "Calls", int64(q.Calls()), 10)
+}
+
+func TestQuotaFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "darksky-quota")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewFileQuotaStore(filepath.Join(dir, "quota.json"))
+	clock := &fakeClock{time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+	q := newTestQuota(t, QuotaConfig{Hard: 10, Store: store}, clock)
+
+	for i := 0; i < 3; i++ {
+		if err := q.reserve(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := newTestQuota(t, QuotaConfig{Hard: 10, Store: store}, clock)
+
+	assertInt(t, "Calls", int64(restored.Calls()), 3)
+
+	clock.t = clock.t.Add(day)
+
+	assertInt(t, "Calls", int64(restored.Calls()), 0)
+
+	state, err := store.Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "Period", state.Period.Unix(), time.Date(2019, 3, 2, 0, 0, 0, 0, time.UTC).Unix())
+}
+
+func TestQuotaFileStoreMissingFile(t *testing.T) {
+	state, err := NewFileQuotaStore(filepath.Join(os.TempDir(), "darksky-missing", "quota.json")).Load()
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	assertInt(t, "Calls", int64(state.Calls), 0)
+}
+
+func TestErrInvalidQuota(t *testing.T) {
+	configs := []QuotaConfig{
+		{},
+		{Hard: 10, Soft: 11},
+		{Hard: 10, Soft: -1},
+		{Hard: 10, ResetAt: 24 * time.Hour},
+	}
+
+	for _, c := range configs {
+		if _, err := NewQuota(c); err != ErrInvalidQuota {
+			t.Errorf("Should have return ErrInvalidQuota for %+v", c)
+		}
+	}
+}
+
+func TestErrNilQuota(t *testing.T) {
+	if _, err := NewAPI("secret", QuotaOption(nil)); err != ErrNilQuota {
+		t.Error("Nil quota should return ErrNilQuota")
+	}
+}
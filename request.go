@@ -31,6 +31,12 @@ var (
 	// ErrExcludeOptionNotUnique occurs when passing non unique exclude options.
 	ErrExcludeOptionNotUnique = errors.New("exclude options must be unique within the same group")
 
+	// ErrInvalidBaseURL occurs when passing a malformed URL to the BaseURLOption.
+	ErrInvalidBaseURL = errors.New("base URL provided is invalid")
+
+	// Endpoint of the official API, used unless the BaseURLOption is provided.
+	defaultBaseURL = &url.URL{Scheme: scheme, Host: host}
+
 	// Supported languages
 	supportedLanguages = []string{
 		"ar",
@@ -115,6 +121,54 @@ func newOptionError(value string) *excludeOptionError {
 	}
 }
 
+type baseURLError struct {
+	value  string
+	reason string
+}
+
+func (be baseURLError) Error() string {
+	return fmt.Sprintf("%s (%s) : %s", ErrInvalidBaseURL, be.reason, be.value)
+}
+
+// Is makes errors.Is(err, ErrInvalidBaseURL) true whatever the reason.
+func (be baseURLError) Is(target error) bool {
+	return target == ErrInvalidBaseURL
+}
+
+func newBaseURLError(value, reason string) *baseURLError {
+	return &baseURLError{
+		value:  value,
+		reason: reason,
+	}
+}
+
+// parseBaseURL validates a base URL made of a scheme, a host and an optional path prefix.
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return nil, newBaseURLError(raw, err.Error())
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, newBaseURLError(raw, "scheme must be http or https")
+	}
+
+	if u.Host == "" {
+		return nil, newBaseURLError(raw, "host is missing")
+	}
+
+	if u.User != nil || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return nil, newBaseURLError(raw, "only scheme, host and path are allowed")
+	}
+
+	return &url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   strings.TrimRight(u.Path, "/"),
+	}, nil
+}
+
 // LanguageOption to have the API response in the specified language.
 func LanguageOption(lang string) Option {
 	return func(v *url.Values) error {
@@ -214,23 +268,23 @@ func toLower(strs []string) []string {
 	return lowStrs
 }
 
-func newForecastRequest(secret string, lat, lng float64, opts []Option) (*http.Request, error) {
+func newForecastRequest(base *url.URL, secret string, lat, lng float64, opts []Option) (*http.Request, error) {
 	path := fmt.Sprintf("/%s/%s/%3.4f,%3.4f", basePath, secret, lat, lng)
 
-	return newRequest(path, opts)
+	return newRequest(base, path, opts)
 }
 
-func newTimeMachineRequest(secret string, lat, lng float64, t time.Time, opts []Option) (*http.Request, error) {
+func newTimeMachineRequest(base *url.URL, secret string, lat, lng float64, t time.Time, opts []Option) (*http.Request, error) {
 	path := fmt.Sprintf("/%s/%s/%3.4f,%3.4f,%d", basePath, secret, lat, lng, int32(t.Unix()))
 
-	return newRequest(path, opts)
+	return newRequest(base, path, opts)
 }
 
-func newRequest(path string, opts []Option) (*http.Request, error) {
+func newRequest(base *url.URL, path string, opts []Option) (*http.Request, error) {
 	url := &url.URL{
-		Scheme: scheme,
-		Host:   host,
-		Path:   path,
+		Scheme: base.Scheme,
+		Host:   base.Host,
+		Path:   base.Path + path,
 	}
 
 	q := url.Query()
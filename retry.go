@@ -0,0 +1,246 @@
+package darksky
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+
+	// Upper bound of what is read from a response body discarded before a retry.
+	maxDiscardedBody = 64 << 10
+)
+
+var (
+	// ErrInvalidRetryPolicy occurs when passing a policy with negative values or a jitter outside
+	// of [0, 1] to the RetryOption.
+	ErrInvalidRetryPolicy = errors.New("retry policy provided is invalid")
+
+	// DefaultRetryableStatus are the status codes retried when RetryPolicy.RetryableStatus is empty.
+	DefaultRetryableStatus = []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// RetryPolicy describes how failed requests are retried. Zero values are replaced by defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Defaults to 3.
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt, doubled after each attempt. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts. A Retry-After asking to wait longer stops
+	// the retries. Defaults to 30s.
+	MaxDelay time.Duration
+	// Jitter randomly shortens each delay by up to this fraction of it, between 0 and 1.
+	Jitter float64
+	// RetryableStatus lists the response status codes to retry. Defaults to DefaultRetryableStatus.
+	RetryableStatus []int
+	// RetryableError reports whether an error returned by the HTTPClient should be retried.
+	// Defaults to IsRetryableError.
+	RetryableError func(error) bool
+	// OnAttempt, when set, is called after each attempt.
+	OnAttempt func(RetryAttempt)
+}
+
+// RetryAttempt describes the outcome of one attempt, given to RetryPolicy.OnAttempt.
+type RetryAttempt struct {
+	// Attempt number, starting at 1.
+	Attempt int
+	// StatusCode of the response, 0 when the HTTPClient returned an error.
+	StatusCode int
+	// Err returned by the HTTPClient.
+	Err error
+	// Retry is true when another attempt will be made after Delay.
+	Retry bool
+	// Delay before the next attempt.
+	Delay time.Duration
+}
+
+// RetryOption to retry requests failing with a transient error according to the given policy.
+func RetryOption(p RetryPolicy) APIOption {
+	return func(api *API) error {
+		if p.MaxAttempts < 0 || p.BaseDelay < 0 || p.MaxDelay < 0 || p.Jitter < 0 || p.Jitter > 1 {
+			return ErrInvalidRetryPolicy
+		}
+
+		if p.MaxAttempts == 0 {
+			p.MaxAttempts = defaultRetryMaxAttempts
+		}
+
+		if p.BaseDelay == 0 {
+			p.BaseDelay = defaultRetryBaseDelay
+		}
+
+		if p.MaxDelay == 0 {
+			p.MaxDelay = defaultRetryMaxDelay
+		}
+
+		if len(p.RetryableStatus) == 0 {
+			p.RetryableStatus = DefaultRetryableStatus
+		}
+
+		if p.RetryableError == nil {
+			p.RetryableError = IsRetryableError
+		}
+
+		api.retry = &p
+
+		return nil
+	}
+}
+
+// IsRetryableError reports whether err is a network error worth retrying: timeouts, connections
+// reset or refused, and connections closed before the end of the response.
+func IsRetryableError(err error) bool {
+	var netErr net.Error
+
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return connectionError(err) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatus {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the attempt following the given one.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+
+	return d
+}
+
+// retryAfter parses the Retry-After header of 429 and 503 responses, which is either a number
+// of seconds or an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	v := resp.Header.Get("Retry-After")
+
+	if v == "" {
+		return 0, false
+	}
+
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// doWithRetry sends the request until it succeeds, fails with a non retryable error or the
+// attempts are exhausted. The last response or error is returned as is.
+func (api *API) doWithRetry(ctx context.Context, r *http.Request) (*http.Response, error) {
+	p := api.retry
+
+	for attempt := 1; ; attempt++ {
+		resp, err := api.send(r)
+
+		info := RetryAttempt{Attempt: attempt, Err: err}
+
+		if err == nil {
+			info.StatusCode = resp.StatusCode
+		}
+
+		if attempt < p.MaxAttempts && ctx.Err() == nil {
+			switch {
+			case err != nil:
+				info.Retry = p.RetryableError(err)
+				info.Delay = p.backoff(attempt)
+			case p.retryableStatus(resp.StatusCode):
+				info.Retry = true
+				info.Delay = p.backoff(attempt)
+
+				if d, ok := retryAfter(resp, time.Now()); ok {
+					info.Delay = d
+					info.Retry = d <= p.MaxDelay
+				}
+			}
+		}
+
+		if p.OnAttempt != nil {
+			p.OnAttempt(info)
+		}
+
+		if !info.Retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			discard(resp.Body, api.logger)
+		}
+
+		if err := api.sleep(ctx, info.Delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// discard drains a response body so the connection can be reused, then closes it.
+func discard(body io.ReadCloser, logger *log.Logger) {
+	if _, err := io.CopyN(ioutil.Discard, body, maxDiscardedBody); err != nil && err != io.EOF {
+		logger.Println(err)
+	}
+
+	closeLogged(body, logger)
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
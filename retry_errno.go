@@ -0,0 +1,14 @@
+//go:build !plan9
+// +build !plan9
+
+package darksky
+
+import (
+	"errors"
+	"syscall"
+)
+
+// connectionError reports whether err is a connection reset or refused.
+func connectionError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
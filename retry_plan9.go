@@ -0,0 +1,7 @@
+package darksky
+
+// connectionError reports whether err is a connection reset or refused. Plan 9 reports them as
+// plain strings, which are not matched.
+func connectionError(err error) bool {
+	return false
+}
@@ -0,0 +1,292 @@
+//go:build !plan9
+// +build !plan9
+
+package darksky
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type retryStep struct {
+	code   int
+	err    error
+	header http.Header
+}
+
+// HTTPClientSequenceMock answers each request with the next step, the last step repeating.
+type HTTPClientSequenceMock struct {
+	steps []retryStep
+	calls int
+}
+
+func (c *HTTPClientSequenceMock) Do(req *http.Request) (*http.Response, error) {
+	step := c.steps[len(c.steps)-1]
+
+	if c.calls < len(c.steps) {
+		step = c.steps[c.calls]
+	}
+
+	c.calls++
+
+	if step.err != nil {
+		return nil, step.err
+	}
+
+	if step.code == http.StatusOK {
+		return ClientMock.Do(req)
+	}
+
+	resp, err := formatResponse(http.StatusText(step.code), step.code, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header.Set("Content-Type", "text/plain")
+
+	for k, v := range step.header {
+		resp.Header[k] = v
+	}
+
+	return resp, nil
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// newRetryAPI creates an API whose sleeps are recorded instead of waited.
+func newRetryAPI(t *testing.T, client HTTPClient, p RetryPolicy) (*API, *[]time.Duration) {
+	api, err := NewAPI("test-secret", HTTPClientOption(client), RetryOption(p))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var delays []time.Duration
+
+	api.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+
+		return ctx.Err()
+	}
+
+	return api, &delays
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	client := &HTTPClientSequenceMock{steps: []retryStep{{code: 500}, {code: 502}, {code: 200}}}
+	api, delays := newRetryAPI(t, client, RetryPolicy{BaseDelay: time.Second})
+
+	d, err := api.Forecast(defaultLat, defaultLng)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateForecast(t, d)
+	assertInt(t, "calls", int64(client.calls), 3)
+	assertDelays(t, *delays, time.Second, 2*time.Second)
+}
+
+func TestRetryOnNetworkError(t *testing.T) {
+	steps := []retryStep{
+		{err: timeoutError{}},
+		{err: syscall.ECONNRESET},
+		{err: io.ErrUnexpectedEOF},
+		{code: 200},
+	}
+	client := &HTTPClientSequenceMock{steps: steps}
+	api, _ := newRetryAPI(t, client, RetryPolicy{MaxAttempts: 4})
+
+	d, err := api.TimeMachine(defaultLat, defaultLng, time.Now())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateTimeMachine(t, d)
+	assertInt(t, "calls", int64(client.calls), 4)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	client := &HTTPClientSequenceMock{steps: []retryStep{{code: 503}}}
+	api, delays := newRetryAPI(t, client, RetryPolicy{MaxAttempts: 3})
+
+	_, err := api.Forecast(defaultLat, defaultLng)
+
+	if !errors.Is(err, ErrServerError) {
+		t.Errorf("Should have return the last server error, got %v", err)
+	}
+
+	assertInt(t, "calls", int64(client.calls), 3)
+	assertInt(t, "sleeps", int64(len(*delays)), 2)
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	steps := []struct {
+		step     retryStep
+		expected error
+	}{
+		{retryStep{code: 400}, ErrBadRequest},
+		{retryStep{code: 403}, ErrUnauthorized},
+		{retryStep{err: errors.New("unsupported protocol scheme")}, nil},
+	}
+
+	for _, s := range steps {
+		client := &HTTPClientSequenceMock{steps: []retryStep{s.step, {code: 200}}}
+		api, _ := newRetryAPI(t, client, RetryPolicy{})
+
+		_, err := api.Forecast(defaultLat, defaultLng)
+
+		if err == nil || (s.expected != nil && !errors.Is(err, s.expected)) {
+			t.Errorf("Should have return the first error, got %v", err)
+		}
+
+		assertInt(t, "calls", int64(client.calls), 1)
+	}
+}
+
+func TestRetryCustomStatus(t *testing.T) {
+	client := &HTTPClientSequenceMock{steps: []retryStep{{code: 500}, {code: 200}}}
+	api, _ := newRetryAPI(t, client, RetryPolicy{RetryableStatus: []int{http.StatusBadGateway}})
+
+	if _, err := api.Forecast(defaultLat, defaultLng); !errors.Is(err, ErrServerError) {
+		t.Errorf("500 should not have been retried, got %v", err)
+	}
+
+	assertInt(t, "calls", int64(client.calls), 1)
+}
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(3 * time.Second).UTC().Format(http.TimeFormat)
+	steps := []retryStep{
+		{code: 429, header: http.Header{"Retry-After": []string{"7"}}},
+		{code: 503, header: http.Header{"Retry-After": []string{date}}},
+		{code: 200},
+	}
+	client := &HTTPClientSequenceMock{steps: steps}
+	api, delays := newRetryAPI(t, client, RetryPolicy{})
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "sleeps", int64(len(*delays)), 2)
+	assertInt(t, "Retry-After seconds", int64((*delays)[0]), int64(7*time.Second))
+
+	if d := (*delays)[1]; d <= time.Second || d > 3*time.Second {
+		t.Errorf("Retry-After date should have given a delay of about 3s, got %s", d)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	steps := []retryStep{
+		{code: 429, header: http.Header{"Retry-After": []string{"3600"}}},
+		{code: 200},
+	}
+	client := &HTTPClientSequenceMock{steps: steps}
+	api, _ := newRetryAPI(t, client, RetryPolicy{MaxDelay: time.Minute})
+
+	if _, err := api.Forecast(defaultLat, defaultLng); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Should have given up on a Retry-After longer than MaxDelay, got %v", err)
+	}
+
+	assertInt(t, "calls", int64(client.calls), 1)
+}
+
+func TestRetryOnAttempt(t *testing.T) {
+	var attempts []RetryAttempt
+
+	client := &HTTPClientSequenceMock{steps: []retryStep{{err: syscall.ECONNREFUSED}, {code: 500}, {code: 200}}}
+	api, _ := newRetryAPI(t, client, RetryPolicy{
+		BaseDelay: time.Millisecond,
+		OnAttempt: func(a RetryAttempt) { attempts = append(attempts, a) },
+	})
+
+	if _, err := api.Forecast(defaultLat, defaultLng); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "attempts", int64(len(attempts)), 3)
+
+	for i, a := range attempts {
+		assertInt(t, "Attempt", int64(a.Attempt), int64(i+1))
+	}
+
+	if !errors.Is(attempts[0].Err, syscall.ECONNREFUSED) || !attempts[0].Retry {
+		t.Error("First attempt should report the network error and a retry")
+	}
+
+	assertInt(t, "StatusCode", int64(attempts[1].StatusCode), 500)
+	assertInt(t, "Delay", int64(attempts[1].Delay), int64(2*time.Millisecond))
+
+	if attempts[2].Retry || attempts[2].StatusCode != 200 {
+		t.Error("Last attempt should be a success without retry")
+	}
+}
+
+func TestRetryCancelledDuringBackoff(t *testing.T) {
+	client := &HTTPClientSequenceMock{steps: []retryStep{{code: 500}}}
+	api, err := NewAPI("test-secret", HTTPClientOption(client), RetryOption(RetryPolicy{BaseDelay: time.Hour}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if _, err := api.ForecastContext(ctx, defaultLat, defaultLng); err != context.Canceled {
+		t.Errorf("Should have return context.Canceled, got %v", err)
+	}
+
+	assertInt(t, "calls", int64(client.calls), 1)
+}
+
+func TestRetryJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 4 * time.Second, Jitter: 0.5}
+
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := p.backoff(attempt + 1)
+
+		if d > max || d < max/2 {
+			t.Errorf("Delay after attempt %d should be between %s and %s, got %s", attempt+1, max/2, max, d)
+		}
+	}
+}
+
+func TestErrInvalidRetryPolicy(t *testing.T) {
+	policies := []RetryPolicy{
+		{MaxAttempts: -1},
+		{BaseDelay: -time.Second},
+		{Jitter: 1.5},
+	}
+
+	for _, p := range policies {
+		if _, err := NewAPI("secret", RetryOption(p)); err != ErrInvalidRetryPolicy {
+			t.Errorf("Should have return ErrInvalidRetryPolicy for %+v", p)
+		}
+	}
+}
+
+func assertDelays(t *testing.T, delays []time.Duration, expected ...time.Duration) {
+	if len(delays) != len(expected) {
+		t.Errorf("Expected %d delays, got %v", len(expected), delays)
+		return
+	}
+
+	for i := range delays {
+		if delays[i] != expected[i] {
+			t.Errorf("Delay %d should be %s, got %s", i, expected[i], delays[i])
+		}
+	}
+}
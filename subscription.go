@@ -0,0 +1,266 @@
+package darksky
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"time"
+)
+
+// ErrInvalidSubscription occurs when creating a subscription without locations, rules or
+// OnNotify, with locations without a unique ID, or with an invalid rule.
+var ErrInvalidSubscription = errors.New("subscription configuration provided is invalid")
+
+// RuleKind tells when a rule fires.
+type RuleKind int
+
+const (
+	// RuleAbove fires when the value rises above the rule value.
+	RuleAbove RuleKind = iota + 1
+	// RuleBelow fires when the value falls below the rule value.
+	RuleBelow
+	// RuleDelta fires when the value changes by more than the rule value between two polls.
+	RuleDelta
+)
+
+// Rule watches a field of a data point of the forecast.
+type Rule struct {
+	// Name identifies the rule, it must be unique.
+	Name string
+	// Block is the block of the data point, ExCurrently, ExHourly or ExDaily.
+	Block string
+	// Index of the data point in the block, like 1 for tomorrow in the daily block. Ignored for
+	// currently.
+	Index int
+	// Field of the data point, named as in the JSON payload, like "precipProbability".
+	Field string
+	Kind  RuleKind
+	// Value is the threshold of RuleAbove and RuleBelow, and the change of RuleDelta.
+	Value float64
+	// Debounce is the time during which the rule does not fire again for a location after firing.
+	Debounce time.Duration
+}
+
+// Notification tells a rule fired for a location.
+type Notification struct {
+	Location WatchLocation
+	Rule     Rule
+	// Time of the data point.
+	Time time.Time
+	// Value of the field, and Previous its value at the previous poll when HasPrevious is true.
+	Value       float64
+	Previous    float64
+	HasPrevious bool
+}
+
+// SubscriptionConfig describes a subscription.
+type SubscriptionConfig struct {
+	Locations []WatchLocation
+	Rules     []Rule
+	// Interval between polls. Defaults to 10 minutes.
+	Interval time.Duration
+	// Options passed to each forecast query.
+	Options []Option
+	// Concurrency is the number of locations polled at once, see BatchConfig.
+	Concurrency int
+	// OnNotify is called each time a rule fires.
+	OnNotify func(Notification)
+	// OnError is called when polling a location fails, the error is logged when nil.
+	OnError func(WatchLocation, error)
+}
+
+// Subscription polls the forecast of several locations and notifies when the rules fire,
+// comparing each forecast with the previous one.
+type Subscription struct {
+	api      *API
+	config   SubscriptionConfig
+	previous map[string]*APIData
+	fired    map[string]time.Time
+	now      func() time.Time
+	sleep    func(context.Context, time.Duration) error
+}
+
+// NewSubscription creates a subscription.
+func NewSubscription(api *API, c SubscriptionConfig) (*Subscription, error) {
+	if api == nil || len(c.Locations) == 0 || len(c.Rules) == 0 || c.Interval < 0 || c.OnNotify == nil {
+		return nil, ErrInvalidSubscription
+	}
+
+	ids := make(map[string]bool)
+
+	for _, l := range c.Locations {
+		if l.ID == "" || ids[l.ID] {
+			return nil, ErrInvalidSubscription
+		}
+
+		ids[l.ID] = true
+	}
+
+	names := make(map[string]bool)
+
+	for _, r := range c.Rules {
+		if r.Name == "" || names[r.Name] || !r.valid() {
+			return nil, ErrInvalidSubscription
+		}
+
+		names[r.Name] = true
+	}
+
+	if c.Interval == 0 {
+		c.Interval = defaultWatchInterval
+	}
+
+	return &Subscription{
+		api:      api,
+		config:   c,
+		previous: make(map[string]*APIData),
+		fired:    make(map[string]time.Time),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}, nil
+}
+
+func (r Rule) valid() bool {
+	i, ok := dataPointFieldIndex[r.Field]
+
+	if !ok {
+		return false
+	}
+
+	switch reflect.TypeOf(DataPoint{}).Field(dataPointFields[i].index).Type.Kind() {
+	case reflect.Float64, reflect.Int64:
+	default:
+		return false
+	}
+
+	switch r.Block {
+	case ExCurrently, ExHourly, ExDaily:
+	default:
+		return false
+	}
+
+	return r.Kind >= RuleAbove && r.Kind <= RuleDelta && r.Index >= 0 && r.Debounce >= 0
+}
+
+// Run polls the locations at each interval until the context is done.
+func (s *Subscription) Run(ctx context.Context) error {
+	for {
+		if err := s.Poll(ctx); err != nil {
+			return err
+		}
+
+		if err := s.sleep(ctx, s.config.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll polls the locations once and notifies the rules firing. Failing to poll a location is
+// reported to OnError, and the next poll compares with the last forecast obtained.
+func (s *Subscription) Poll(ctx context.Context) error {
+	return pollLocations(ctx, s.api, s.config.Locations, s.config.Options, s.config.Concurrency,
+		func(l WatchLocation, data *APIData) error {
+			s.update(l, data)
+
+			return nil
+		},
+		func(l WatchLocation, err error) {
+			if s.config.OnError != nil {
+				s.config.OnError(l, err)
+				return
+			}
+
+			s.api.logger.Printf("polling forecast of %s failed: %v", l.ID, err)
+		})
+}
+
+func (s *Subscription) update(l WatchLocation, data *APIData) {
+	prev := s.previous[l.ID]
+	now := s.now()
+
+	for _, r := range s.config.Rules {
+		n, ok := r.evaluate(prev, data)
+
+		if !ok {
+			continue
+		}
+
+		key := l.ID + "\n" + r.Name
+
+		if last, ok := s.fired[key]; ok && now.Sub(last) < r.Debounce {
+			continue
+		}
+
+		s.fired[key] = now
+		n.Location = l
+		s.config.OnNotify(n)
+	}
+
+	s.previous[l.ID] = data
+}
+
+// evaluate tells whether the rule fires for data, compared with prev which may be nil.
+func (r Rule) evaluate(prev, data *APIData) (Notification, bool) {
+	p, ok := r.point(data, nil)
+
+	if !ok {
+		return Notification{}, false
+	}
+
+	v, ok := p.Float(r.Field)
+
+	if !ok {
+		return Notification{}, false
+	}
+
+	n := Notification{Rule: r, Time: p.LocalTime(), Value: v}
+
+	if prev != nil {
+		if pp, ok := r.point(prev, &p); ok {
+			n.Previous, n.HasPrevious = pp.Float(r.Field)
+		}
+	}
+
+	switch r.Kind {
+	case RuleAbove:
+		return n, v > r.Value && !(n.HasPrevious && n.Previous > r.Value)
+	case RuleBelow:
+		return n, v < r.Value && !(n.HasPrevious && n.Previous < r.Value)
+	case RuleDelta:
+		return n, n.HasPrevious && math.Abs(v-n.Previous) > r.Value
+	}
+
+	return Notification{}, false
+}
+
+// point returns the data point of the rule. When same is given, the point of the block with
+// the same time is returned instead, so points are compared even after the day or hour changed.
+func (r Rule) point(data *APIData, same *DataPoint) (DataPoint, bool) {
+	var b *DataBlock
+
+	switch r.Block {
+	case ExCurrently:
+		return data.Currently, len(data.Currently.Fields()) > 0
+	case ExHourly:
+		b = &data.Hourly
+	case ExDaily:
+		b = &data.Daily
+	}
+
+	if same != nil {
+		for _, p := range b.Data {
+			if p.Time == same.Time {
+				return p, true
+			}
+		}
+
+		return DataPoint{}, false
+	}
+
+	if r.Index >= len(b.Data) {
+		return DataPoint{}, false
+	}
+
+	return b.Data[r.Index], true
+}
@@ -0,0 +1,139 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTPClientDataMock answers with the data currently set.
+type HTTPClientDataMock struct {
+	mu   sync.Mutex
+	data APIData
+}
+
+func (c *HTTPClientDataMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	content, err := json.Marshal(c.data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return formatResponse(string(content), 200, req)
+}
+
+// setDays sets the daily block to days starting at day, with the precipitation probabilities
+// and high temperatures given in pairs, and the current temperature.
+func (c *HTTPClientDataMock) setDays(day int64, temperature float64, days ...float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.data = APIData{Timezone: "UTC", Currently: DataPoint{Time: day, Temperature: temperature}}
+	c.data.Currently.MarkPresent("temperature")
+
+	for i := 0; i+1 < len(days); i += 2 {
+		c.data.Daily.Data = append(c.data.Daily.Data, DataPoint{
+			Time:              day + int64(i/2)*86400,
+			PrecipProbability: days[i],
+			TemperatureHigh:   days[i+1],
+		})
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	client := &HTTPClientDataMock{}
+	api, _ := NewAPI("test-secret", HTTPClientOption(client))
+
+	var fired []string
+
+	s, err := NewSubscription(api, SubscriptionConfig{
+		Locations: []WatchLocation{{ID: "boston", Lat: 42.3601, Lng: -71.0589}},
+		Rules: []Rule{
+			{Name: "rain tomorrow", Block: ExDaily, Index: 1, Field: "precipProbability", Kind: RuleAbove, Value: 0.6, Debounce: time.Hour},
+			{Name: "high change", Block: ExDaily, Index: 1, Field: "temperatureHigh", Kind: RuleDelta, Value: 3},
+			{Name: "freezing", Block: ExCurrently, Field: "temperature", Kind: RuleBelow, Value: 0},
+		},
+		OnNotify: func(n Notification) {
+			fired = append(fired, n.Location.ID+" "+n.Rule.Name)
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	poll := func(expected ...string) {
+		fired = nil
+
+		if err := s.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		assertEvents(t, fired, expected...)
+	}
+
+	client.setDays(0, 2, 0.1, 40, 0.5, 50)
+	poll()
+
+	client.setDays(0, 0, 0.1, 40, 0.7, 54)
+	poll("boston rain tomorrow", "boston high change")
+
+	client.setDays(0, 0, 0.1, 40, 0.5, 52)
+	poll()
+
+	client.setDays(0, -1, 0.1, 40, 0.8, 52)
+	poll("boston freezing")
+
+	now = now.Add(2 * time.Hour)
+	client.setDays(0, -1, 0.1, 40, 0.5, 52)
+	poll()
+
+	client.setDays(0, -1, 0.1, 40, 0.9, 52)
+	poll("boston rain tomorrow")
+
+	// The next day, tomorrow is another day, the high is not compared with the previous one.
+	client.setDays(86400, -1, 0.9, 52, 0.9, 70)
+	poll()
+}
+
+func TestNewSubscriptionInvalid(t *testing.T) {
+	api, _ := NewAPI("test-secret")
+	locations := []WatchLocation{{ID: "a"}}
+	onNotify := func(Notification) {}
+	rule := Rule{Name: "r", Block: ExDaily, Field: "temperatureHigh", Kind: RuleAbove}
+
+	rules := []Rule{
+		{Block: ExDaily, Field: "temperatureHigh", Kind: RuleAbove},
+		{Name: "r", Block: ExMinutely, Field: "temperatureHigh", Kind: RuleAbove},
+		{Name: "r", Block: ExDaily, Field: "summary", Kind: RuleAbove},
+		{Name: "r", Block: ExDaily, Field: "unknown", Kind: RuleAbove},
+		{Name: "r", Block: ExDaily, Field: "temperatureHigh"},
+		{Name: "r", Block: ExDaily, Field: "temperatureHigh", Kind: RuleAbove, Index: -1},
+	}
+
+	for i, r := range rules {
+		_, err := NewSubscription(api, SubscriptionConfig{Locations: locations, Rules: []Rule{r}, OnNotify: onNotify})
+
+		if err != ErrInvalidSubscription {
+			t.Errorf("Rule %d: expected ErrInvalidSubscription, got %v", i, err)
+		}
+	}
+
+	if _, err := NewSubscription(api, SubscriptionConfig{Locations: locations, Rules: []Rule{rule, rule}, OnNotify: onNotify}); err != ErrInvalidSubscription {
+		t.Errorf("Duplicate rules: expected ErrInvalidSubscription, got %v", err)
+	}
+
+	if _, err := NewSubscription(api, SubscriptionConfig{Locations: locations, Rules: []Rule{rule}}); err != ErrInvalidSubscription {
+		t.Errorf("Missing OnNotify: expected ErrInvalidSubscription, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package darksky
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidTimeRange occurs when the end of a time range is before its start.
+var ErrInvalidTimeRange = errors.New("end of time range cannot be before its start")
+
+// TimeMachineRange queries the API once per day between start and end, days being those of the
+// timezone of the location, and stitches the responses into a single APIData. Hourly and daily
+// data points of all the days are merged, de-duplicated and sorted by time, while the other fields
+// come from the response for the day of start. Queries after the first one run as a batch
+// configured by c, the first error met being returned.
+func (api API) TimeMachineRange(ctx context.Context, lat, lng float64, start, end time.Time, c BatchConfig, opts ...Option) (*APIData, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	// The first response tells the timezone of the location, needed to split the range in days.
+	first, err := api.TimeMachineContext(ctx, lat, lng, start, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	loc := first.Location()
+
+	var queries []BatchQuery
+
+	for _, d := range rangeDays(start, end, loc)[1:] {
+		queries = append(queries, BatchQuery{Lat: lat, Lng: lng, Time: d, Options: opts})
+	}
+
+	days := []*APIData{first}
+
+	for _, r := range api.Batch(ctx, queries, c) {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+
+		days = append(days, r.Data)
+	}
+
+	return mergeDays(days), nil
+}
+
+// rangeDays returns a time at noon, which exists whatever the daylight saving time transitions,
+// for each day between start and end in loc.
+func rangeDays(start, end time.Time, loc *time.Location) []time.Time {
+	s, e := start.In(loc), end.In(loc)
+	day := time.Date(s.Year(), s.Month(), s.Day(), 12, 0, 0, 0, loc)
+	last := time.Date(e.Year(), e.Month(), e.Day(), 12, 0, 0, 0, loc)
+
+	var days []time.Time
+
+	for !day.After(last) {
+		days = append(days, day)
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 12, 0, 0, 0, loc)
+	}
+
+	return days
+}
+
+func mergeDays(days []*APIData) *APIData {
+	merged := *days[0]
+
+	if len(days) == 1 {
+		return &merged
+	}
+
+	var hourly, daily []DataPoint
+
+	for _, d := range days {
+		hourly = append(hourly, d.Hourly.Data...)
+		daily = append(daily, d.Daily.Data...)
+	}
+
+	// Summaries and icons describe a single day, they do not apply to the merged blocks.
+	merged.Hourly = DataBlock{Data: sortPoints(hourly)}
+	merged.Daily = DataBlock{Data: sortPoints(daily)}
+	merged.Localize()
+
+	return &merged
+}
+
+// sortPoints sorts data points by time, keeping the first of those sharing the same time.
+func sortPoints(points []DataPoint) []DataPoint {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time < points[j].Time
+	})
+
+	unique := points[:0]
+
+	for _, p := range points {
+		if len(unique) == 0 || p.Time != unique[len(unique)-1].Time {
+			unique = append(unique, p)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,148 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTPClientDaysMock answers time machine requests with the hourly and daily data of the day
+// containing the requested time in its location, plus the first hour of the following day.
+type HTTPClientDaysMock struct {
+	mu    sync.Mutex
+	loc   *time.Location
+	times []time.Time
+}
+
+func (c *HTTPClientDaysMock) Do(req *http.Request) (*http.Response, error) {
+	params := strings.Split(req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:], ",")
+	ts, err := strconv.ParseInt(params[2], 10, 64)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.Unix(ts, 0).In(c.loc)
+
+	c.mu.Lock()
+	c.times = append(c.times, t)
+	c.mu.Unlock()
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	next := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+
+	data := APIData{
+		Timezone:  c.loc.String(),
+		Currently: DataPoint{Time: ts, Summary: t.Format("2006-01-02")},
+		Daily:     DataBlock{Summary: "Daily", Data: []DataPoint{{Time: midnight.Unix()}}},
+		Hourly:    DataBlock{Summary: "Hourly"},
+	}
+
+	for h := midnight; !h.After(next); h = h.Add(time.Hour) {
+		data.Hourly.Data = append(data.Hourly.Data, DataPoint{Time: h.Unix()})
+	}
+
+	body, err := json.Marshal(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return formatResponse(string(body), 200, req)
+}
+
+func TestTimeMachineRange(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+
+	if err != nil {
+		t.Skip(err)
+	}
+
+	client := &HTTPClientDaysMock{loc: loc}
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Crosses the daylight saving time start of 2019-03-10, which has 23 hours.
+	start := time.Date(2019, 3, 8, 22, 0, 0, 0, loc)
+	end := time.Date(2019, 3, 11, 1, 0, 0, 0, loc)
+
+	d, err := api.TimeMachineRange(context.Background(), defaultLat, defaultLng, start, end, BatchConfig{Concurrency: 2})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(client.times)), 4)
+	assertString(t, "Currently.Summary", d.Currently.Summary, "2019-03-08")
+	assertInt(t, "Currently.Time", d.Currently.Time, start.Unix())
+	assertInt(t, "Daily points", int64(len(d.Daily.Data)), 4)
+	assertInt(t, "Hourly points", int64(len(d.Hourly.Data)), 24+24+23+24+1)
+	assertString(t, "Hourly.Summary", d.Hourly.Summary, "")
+	assertString(t, "Hourly.Location", d.Hourly.Location().String(), loc.String())
+	assertString(t, "Daily.Location", d.Daily.Location().String(), loc.String())
+
+	for i := 1; i < len(d.Hourly.Data); i++ {
+		if d.Hourly.Data[i].Time-d.Hourly.Data[i-1].Time != 3600 {
+			t.Fatalf("Hourly data should be continuous, got %d then %d", d.Hourly.Data[i-1].Time, d.Hourly.Data[i].Time)
+		}
+	}
+
+	for i := 1; i < len(d.Daily.Data); i++ {
+		if d.Daily.Data[i].Time <= d.Daily.Data[i-1].Time {
+			t.Fatal("Daily data should be sorted")
+		}
+	}
+}
+
+func TestTimeMachineRangeSingleDay(t *testing.T) {
+	client := &HTTPClientDaysMock{loc: time.UTC}
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2019, 3, 8, 1, 0, 0, 0, time.UTC)
+
+	d, err := api.TimeMachineRange(context.Background(), defaultLat, defaultLng, start, start.Add(time.Hour), BatchConfig{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "requests", int64(len(client.times)), 1)
+	assertString(t, "Hourly.Summary", d.Hourly.Summary, "Hourly")
+}
+
+func TestTimeMachineRangeErrors(t *testing.T) {
+	api, err := NewAPI("test-secret", HTTPClientOption(&HTTPClientDaysMock{loc: time.UTC}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	if _, err := api.TimeMachineRange(context.Background(), defaultLat, defaultLng, now, now.Add(-time.Hour), BatchConfig{}); err != ErrInvalidTimeRange {
+		t.Errorf("Should have return ErrInvalidTimeRange, got %v", err)
+	}
+
+	errClient := newErrorClient(500, "Server Error", "text/plain")
+	api, err = NewAPI("test-secret", HTTPClientOption(errClient))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.TimeMachineRange(context.Background(), defaultLat, defaultLng, now.AddDate(0, 0, -3), now, BatchConfig{}); err == nil {
+		t.Error("Should have return the error of the queries")
+	}
+}
@@ -0,0 +1,278 @@
+package darksky
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// ErrIncompatibleUnits occurs when converting a quantity to a unit measuring something else.
+var ErrIncompatibleUnits = errors.New("incompatible units")
+
+// Measure is the kind of physical quantity a unit measures.
+type Measure int
+
+const (
+	// MeasureTemperature of the air or the dew point.
+	MeasureTemperature Measure = iota + 1
+	// MeasureSpeed of the wind.
+	MeasureSpeed
+	// MeasureDistance like the visibility or the nearest storm distance.
+	MeasureDistance
+	// MeasurePressure of the sea-level air.
+	MeasurePressure
+	// MeasurePrecipIntensity of the precipitation, per hour.
+	MeasurePrecipIntensity
+	// MeasurePrecipAccumulation of snowfall.
+	MeasurePrecipAccumulation
+)
+
+// Unit of a quantity.
+type Unit string
+
+const (
+	// Celsius degrees.
+	Celsius Unit = "°C"
+	// Fahrenheit degrees.
+	Fahrenheit Unit = "°F"
+	// MetersPerSecond speed.
+	MetersPerSecond Unit = "m/s"
+	// KilometersPerHour speed.
+	KilometersPerHour Unit = "km/h"
+	// MilesPerHour speed.
+	MilesPerHour Unit = "mph"
+	// Kilometers distance.
+	Kilometers Unit = "km"
+	// Miles distance.
+	Miles Unit = "mi"
+	// Hectopascals pressure, the same as millibars.
+	Hectopascals Unit = "hPa"
+	// MillimetersPerHour precipitation intensity.
+	MillimetersPerHour Unit = "mm/h"
+	// InchesPerHour precipitation intensity.
+	InchesPerHour Unit = "in/h"
+	// Centimeters precipitation accumulation.
+	Centimeters Unit = "cm"
+	// Inches precipitation accumulation.
+	Inches Unit = "in"
+)
+
+// unitScale converts a value of a unit to the base unit of its measure, as value*scale+offset.
+type unitScale struct {
+	measure       Measure
+	scale, offset float64
+}
+
+var unitScales = map[Unit]unitScale{
+	Celsius:            {MeasureTemperature, 1, 0},
+	Fahrenheit:         {MeasureTemperature, 5.0 / 9, -32 * 5.0 / 9},
+	MetersPerSecond:    {MeasureSpeed, 1, 0},
+	KilometersPerHour:  {MeasureSpeed, 1 / 3.6, 0},
+	MilesPerHour:       {MeasureSpeed, 0.44704, 0},
+	Kilometers:         {MeasureDistance, 1, 0},
+	Miles:              {MeasureDistance, 1.609344, 0},
+	Hectopascals:       {MeasurePressure, 1, 0},
+	MillimetersPerHour: {MeasurePrecipIntensity, 1, 0},
+	InchesPerHour:      {MeasurePrecipIntensity, 25.4, 0},
+	Centimeters:        {MeasurePrecipAccumulation, 1, 0},
+	Inches:             {MeasurePrecipAccumulation, 2.54, 0},
+}
+
+// unitSystems gives the unit of each measure in the unit systems of the API.
+var unitSystems = map[string]map[Measure]Unit{
+	UnitUS: {
+		MeasureTemperature:        Fahrenheit,
+		MeasureSpeed:              MilesPerHour,
+		MeasureDistance:           Miles,
+		MeasurePressure:           Hectopascals,
+		MeasurePrecipIntensity:    InchesPerHour,
+		MeasurePrecipAccumulation: Inches,
+	},
+	UnitSI: {
+		MeasureTemperature:        Celsius,
+		MeasureSpeed:              MetersPerSecond,
+		MeasureDistance:           Kilometers,
+		MeasurePressure:           Hectopascals,
+		MeasurePrecipIntensity:    MillimetersPerHour,
+		MeasurePrecipAccumulation: Centimeters,
+	},
+	UnitCA: {
+		MeasureTemperature:        Celsius,
+		MeasureSpeed:              KilometersPerHour,
+		MeasureDistance:           Kilometers,
+		MeasurePressure:           Hectopascals,
+		MeasurePrecipIntensity:    MillimetersPerHour,
+		MeasurePrecipAccumulation: Centimeters,
+	},
+	UnitUK2: {
+		MeasureTemperature:        Celsius,
+		MeasureSpeed:              MilesPerHour,
+		MeasureDistance:           Miles,
+		MeasurePressure:           Hectopascals,
+		MeasurePrecipIntensity:    MillimetersPerHour,
+		MeasurePrecipAccumulation: Centimeters,
+	},
+}
+
+// fieldMeasures gives the measure of the data point fields with a unit, by JSON name.
+var fieldMeasures = map[string]Measure{
+	"apparentTemperature":     MeasureTemperature,
+	"apparentTemperatureHigh": MeasureTemperature,
+	"apparentTemperatureLow":  MeasureTemperature,
+	"apparentTemperatureMax":  MeasureTemperature,
+	"apparentTemperatureMin":  MeasureTemperature,
+	"dewPoint":                MeasureTemperature,
+	"temperature":             MeasureTemperature,
+	"temperatureHigh":         MeasureTemperature,
+	"temperatureLow":          MeasureTemperature,
+	"temperatureMax":          MeasureTemperature,
+	"temperatureMin":          MeasureTemperature,
+	"windGust":                MeasureSpeed,
+	"windSpeed":               MeasureSpeed,
+	"nearestStormDistance":    MeasureDistance,
+	"visibility":              MeasureDistance,
+	"pressure":                MeasurePressure,
+	"precipIntensity":         MeasurePrecipIntensity,
+	"precipIntensityError":    MeasurePrecipIntensity,
+	"precipIntensityMax":      MeasurePrecipIntensity,
+	"precipAccumulation":      MeasurePrecipAccumulation,
+}
+
+// Measure of the unit, 0 when unknown.
+func (u Unit) Measure() Measure {
+	return unitScales[u].measure
+}
+
+// UnitOf returns the unit of the measure in a unit system, one of UnitUS, UnitSI, UnitCA or UnitUK2.
+func UnitOf(system string, m Measure) (Unit, error) {
+	units, ok := unitSystems[system]
+
+	if !ok {
+		return "", ErrUnitNotSupported
+	}
+
+	return units[m], nil
+}
+
+// Quantity is a value with its unit.
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+// In converts the quantity to another unit of the same measure.
+func (q Quantity) In(u Unit) (Quantity, error) {
+	from, ok := unitScales[q.Unit]
+	to, ok2 := unitScales[u]
+
+	if !ok || !ok2 || from.measure != to.measure {
+		return Quantity{}, ErrIncompatibleUnits
+	}
+
+	return Quantity{(q.Value*from.scale + from.offset - to.offset) / to.scale, u}, nil
+}
+
+func (q Quantity) String() string {
+	return strconv.FormatFloat(q.Value, 'f', -1, 64) + " " + string(q.Unit)
+}
+
+// Quantity returns the value of a field with a unit, named as in the JSON payload, in the unit
+// system of the data. It is false when the data point does not have the field, the field has no
+// unit, or the unit system is unknown.
+func (dp DataPoint) Quantity(field string) (Quantity, bool) {
+	m, ok := fieldMeasures[field]
+
+	if !ok {
+		return Quantity{}, false
+	}
+
+	unit, err := UnitOf(dp.units, m)
+
+	if err != nil {
+		return Quantity{}, false
+	}
+
+	v, ok := dp.Float(field)
+
+	return Quantity{v, unit}, ok
+}
+
+// Convert returns a copy of the data with the values of the data points converted to a unit
+// system, one of UnitUS, UnitSI, UnitCA or UnitUK2. Extra properties are copied as they are.
+func (d *APIData) Convert(system string) (*APIData, error) {
+	if _, ok := unitSystems[system]; !ok {
+		return nil, ErrUnitNotSupported
+	}
+
+	if _, ok := unitSystems[d.Flags.Units]; !ok {
+		return nil, ErrUnitNotSupported
+	}
+
+	content, err := json.Marshal(d)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var c APIData
+
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+
+	c.Metadata = d.Metadata
+
+	points := []*DataPoint{&c.Currently}
+
+	for _, b := range []*DataBlock{&c.Minutely, &c.Hourly, &c.Daily} {
+		for i := range b.Data {
+			points = append(points, &b.Data[i])
+		}
+	}
+
+	for _, p := range points {
+		if err := p.convert(d.Flags.Units, system); err != nil {
+			return nil, err
+		}
+	}
+
+	c.Flags.Units = system
+	c.Localize()
+
+	return &c, nil
+}
+
+func (dp *DataPoint) convert(from, to string) error {
+	v := reflect.ValueOf(dp).Elem()
+
+	for field, m := range fieldMeasures {
+		q, ok := dp.Float(field)
+
+		if !ok {
+			continue
+		}
+
+		fromUnit, _ := UnitOf(from, m)
+		toUnit, _ := UnitOf(to, m)
+
+		converted, err := Quantity{q, fromUnit}.In(toUnit)
+
+		if err != nil {
+			return err
+		}
+
+		fv := v.Field(dataPointFields[dataPointFieldIndex[field]].index)
+
+		if fv.Kind() == reflect.Int64 {
+			fv.SetInt(int64(math.Round(converted.Value)))
+		} else {
+			fv.SetFloat(converted.Value)
+		}
+
+		dp.MarkPresent(field)
+	}
+
+	return nil
+}
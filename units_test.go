@@ -0,0 +1,149 @@
+package darksky
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func assertNear(t *testing.T, name string, value, expected float64) {
+	if math.Abs(value-expected) > 1e-6 {
+		t.Errorf("Field %s expected to be %f, got %f", name, expected, value)
+	}
+}
+
+func TestQuantityIn(t *testing.T) {
+	cases := []struct {
+		q        Quantity
+		unit     Unit
+		expected float64
+	}{
+		{Quantity{32, Fahrenheit}, Celsius, 0},
+		{Quantity{-40, Celsius}, Fahrenheit, -40},
+		{Quantity{100, Celsius}, Fahrenheit, 212},
+		{Quantity{10, MetersPerSecond}, KilometersPerHour, 36},
+		{Quantity{1, MilesPerHour}, MetersPerSecond, 0.44704},
+		{Quantity{1, Miles}, Kilometers, 1.609344},
+		{Quantity{1, InchesPerHour}, MillimetersPerHour, 25.4},
+		{Quantity{2.54, Centimeters}, Inches, 1},
+		{Quantity{1013.25, Hectopascals}, Hectopascals, 1013.25},
+	}
+
+	for _, c := range cases {
+		got, err := c.q.In(c.unit)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertNear(t, c.q.String()+" in "+string(c.unit), got.Value, c.expected)
+	}
+
+	if _, err := (Quantity{1, Miles}).In(Celsius); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("Expected ErrIncompatibleUnits, got %v", err)
+	}
+
+	assertString(t, "String", Quantity{48.42, Fahrenheit}.String(), "48.42 °F")
+}
+
+func TestUnitOf(t *testing.T) {
+	u, err := UnitOf(UnitCA, MeasureSpeed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "ca speed", string(u), string(KilometersPerHour))
+
+	u, _ = UnitOf(UnitUK2, MeasureDistance)
+
+	assertString(t, "uk2 distance", string(u), string(Miles))
+
+	if _, err := UnitOf(UnitAuto, MeasureSpeed); !errors.Is(err, ErrUnitNotSupported) {
+		t.Errorf("Expected ErrUnitNotSupported, got %v", err)
+	}
+}
+
+func TestDataPointQuantity(t *testing.T) {
+	var d APIData
+
+	if err := json.Unmarshal([]byte(forecastResponseStub), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	q, ok := d.Currently.Quantity("temperature")
+
+	if !ok {
+		t.Fatal("Temperature should have a quantity")
+	}
+
+	assertString(t, "temperature", q.String(), "48.42 °F")
+
+	if _, ok := d.Currently.Quantity("humidity"); ok {
+		t.Error("Humidity has no unit")
+	}
+
+	if _, ok := d.Currently.Quantity("temperatureMin"); ok {
+		t.Error("Missing fields have no quantity")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var d APIData
+
+	if err := json.Unmarshal([]byte(forecastResponseStub), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	si, err := d.Convert(UnitSI)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertString(t, "Flags.Units", si.Flags.Units, UnitSI)
+	assertNear(t, "Currently.Temperature", si.Currently.Temperature, (48.42-32)*5/9)
+	assertNear(t, "Currently.WindSpeed", si.Currently.WindSpeed, 3.35*0.44704)
+	assertNear(t, "Currently.Visibility", si.Currently.Visibility, 5.72*1.609344)
+	assertInt(t, "Currently.NearestStormDistance", si.Currently.NearestStormDistance, 19)
+	assertNear(t, "Currently.Pressure", si.Currently.Pressure, 1027.1)
+	assertNear(t, "Daily.Data[0].PrecipIntensityMax", si.Daily.Data[0].PrecipIntensityMax, 0.0018*25.4)
+	assertNear(t, "Daily.Data[0].TemperatureMin", si.Daily.Data[0].TemperatureMin, (47.17-32)*5/9)
+	assertFloat(t, "Currently.Humidity", si.Currently.Humidity, 0.85)
+
+	if !si.Currently.Has("precipIntensity") {
+		t.Error("Converted data should keep present zero values")
+	}
+
+	q, _ := si.Hourly.Data[0].Quantity("windSpeed")
+
+	assertString(t, "Hourly.Data[0].windSpeed unit", string(q.Unit), string(MetersPerSecond))
+	assertFloat(t, "original Currently.Temperature", d.Currently.Temperature, 48.42)
+
+	ca, _ := si.Convert(UnitCA)
+
+	assertNear(t, "ca Currently.WindSpeed", ca.Currently.WindSpeed, 3.35*1.609344)
+
+	us, _ := ca.Convert(UnitUS)
+
+	assertNear(t, "us Currently.Temperature", us.Currently.Temperature, 48.42)
+
+	if _, err := d.Convert(UnitAuto); !errors.Is(err, ErrUnitNotSupported) {
+		t.Errorf("Expected ErrUnitNotSupported, got %v", err)
+	}
+}
+
+func TestConvertFreezing(t *testing.T) {
+	d := APIData{Currently: DataPoint{Time: 1, Temperature: 32}, Flags: Flags{Units: UnitUS}}
+
+	si, err := d.Convert(UnitSI)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !si.Currently.Has("temperature") {
+		t.Error("A temperature converted to zero should stay present")
+	}
+}
@@ -0,0 +1,330 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const defaultWatchInterval = 10 * time.Minute
+
+// ErrInvalidWatcher occurs when creating a watcher without locations, events destination, with
+// locations without a unique ID, or with a negative interval.
+var ErrInvalidWatcher = errors.New("watcher configuration provided is invalid")
+
+// WatchLocation is a location to watch.
+type WatchLocation struct {
+	// ID identifies the location in events and in the saved state, it must be unique.
+	ID  string
+	Lat float64
+	Lng float64
+}
+
+// AlertEventType tells what happened to an alert.
+type AlertEventType int
+
+const (
+	// AlertNew the alert was not seen before.
+	AlertNew AlertEventType = iota + 1
+	// AlertUpdated the alert was seen before, with another time, expiry, severity, description or
+	// regions.
+	AlertUpdated
+	// AlertExpired the alert is no longer returned, or its expiry passed.
+	AlertExpired
+)
+
+func (t AlertEventType) String() string {
+	switch t {
+	case AlertNew:
+		return "new"
+	case AlertUpdated:
+		return "updated"
+	case AlertExpired:
+		return "expired"
+	}
+
+	return "unknown"
+}
+
+// AlertEvent is a change of the alerts of a location.
+type AlertEvent struct {
+	Type     AlertEventType
+	Location WatchLocation
+	Alert    Alert
+	// Previous is the alert as seen before an update, nil otherwise.
+	Previous *Alert
+}
+
+// AlertWatcherConfig describes an alert watcher.
+type AlertWatcherConfig struct {
+	Locations []WatchLocation
+	// Interval between polls. Defaults to 10 minutes.
+	Interval time.Duration
+	// Options passed to each forecast query.
+	Options []Option
+	// AlertsOnly excludes every block but the alerts from the responses.
+	AlertsOnly bool
+	// Concurrency is the number of locations polled at once, see BatchConfig.
+	Concurrency int
+	// StatePath is the file the seen alerts are saved to, and loaded from, so a restarted watcher
+	// does not report them again. Empty to keep them in memory only. Alerts are saved once their
+	// events are delivered, so an event interrupted by a cancellation or a crash is reported again.
+	StatePath string
+	// OnEvent is called with each event.
+	OnEvent func(AlertEvent)
+	// Events receives each event, the watcher waits for it to be received. It is not closed by the
+	// watcher.
+	Events chan<- AlertEvent
+	// OnError is called when polling a location fails, the error is logged when nil.
+	OnError func(WatchLocation, error)
+}
+
+// AlertWatcher polls the forecast of several locations and reports their new, updated and
+// expired alerts.
+type AlertWatcher struct {
+	api    *API
+	config AlertWatcherConfig
+	state  *alertWatcherState
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+}
+
+// alertWatcherState holds the alerts seen for each location, by alert key.
+type alertWatcherState struct {
+	path      string
+	Locations map[string]map[string]Alert `json:"locations"`
+}
+
+// NewAlertWatcher creates a watcher, loading the seen alerts if the state file exists.
+func NewAlertWatcher(api *API, c AlertWatcherConfig) (*AlertWatcher, error) {
+	if api == nil || len(c.Locations) == 0 || c.Interval < 0 || (c.OnEvent == nil && c.Events == nil) {
+		return nil, ErrInvalidWatcher
+	}
+
+	ids := make(map[string]bool)
+
+	for _, l := range c.Locations {
+		if l.ID == "" || ids[l.ID] {
+			return nil, ErrInvalidWatcher
+		}
+
+		ids[l.ID] = true
+	}
+
+	if c.Interval == 0 {
+		c.Interval = defaultWatchInterval
+	}
+
+	if c.AlertsOnly {
+		c.Options = append(c.Options[:len(c.Options):len(c.Options)],
+			ExcludeOption(ExCurrently, ExMinutely, ExHourly, ExDaily, ExFlags))
+	}
+
+	state, err := loadAlertWatcherState(c.StatePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AlertWatcher{
+		api:    api,
+		config: c,
+		state:  state,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}, nil
+}
+
+// Run polls the locations at each interval until the context is done, or saving the state fails.
+func (w *AlertWatcher) Run(ctx context.Context) error {
+	for {
+		if err := w.Poll(ctx); err != nil {
+			return err
+		}
+
+		if err := w.sleep(ctx, w.config.Interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll polls the locations once and reports the changes of their alerts. Failing to poll a
+// location is reported to OnError, and its alerts are left as they were.
+func (w *AlertWatcher) Poll(ctx context.Context) error {
+	return pollLocations(ctx, w.api, w.config.Locations, w.config.Options, w.config.Concurrency,
+		func(l WatchLocation, data *APIData) error {
+			return w.update(ctx, l, data.Alerts)
+		},
+		func(l WatchLocation, err error) {
+			if w.config.OnError != nil {
+				w.config.OnError(l, err)
+				return
+			}
+
+			w.api.logger.Printf("polling alerts of %s failed: %v", l.ID, err)
+		})
+}
+
+// pollLocations queries the forecast of each location, concurrently, then passes the responses
+// to handle in the order of the locations, and the failures to report.
+func pollLocations(ctx context.Context, api *API, locations []WatchLocation, opts []Option, concurrency int,
+	handle func(WatchLocation, *APIData) error, report func(WatchLocation, error)) error {
+	queries := make([]BatchQuery, len(locations))
+
+	for i, l := range locations {
+		queries[i] = BatchQuery{Lat: l.Lat, Lng: l.Lng, Options: opts}
+	}
+
+	results := api.Batch(ctx, queries, BatchConfig{Concurrency: concurrency})
+
+	for i, r := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if r.Err != nil {
+			report(locations[i], r.Err)
+			continue
+		}
+
+		if err := handle(locations[i], r.Data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// update compares the alerts of a location with those seen, reports the changes, then saves them.
+func (w *AlertWatcher) update(ctx context.Context, l WatchLocation, alerts Alerts) error {
+	seen := w.state.Locations[l.ID]
+	current := make(map[string]Alert)
+	now := w.now()
+
+	var events []AlertEvent
+
+	for _, a := range alerts {
+		if a.Expires != 0 && a.Expires <= now.Unix() {
+			continue
+		}
+
+		key := alertKey(a)
+		current[key] = a
+
+		prev, ok := seen[key]
+
+		switch {
+		case !ok:
+			events = append(events, AlertEvent{Type: AlertNew, Location: l, Alert: a})
+		case alertChanged(prev, a):
+			events = append(events, AlertEvent{Type: AlertUpdated, Location: l, Alert: a, Previous: &prev})
+		}
+	}
+
+	var expired []string
+
+	for key := range seen {
+		if _, ok := current[key]; !ok {
+			expired = append(expired, key)
+		}
+	}
+
+	sort.Strings(expired)
+
+	for _, key := range expired {
+		events = append(events, AlertEvent{Type: AlertExpired, Location: l, Alert: seen[key]})
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	// The alerts are only saved as seen once all their events are delivered, so events are
+	// reported again rather than lost when delivery is interrupted.
+	for _, e := range events {
+		if err := w.emit(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	w.state.Locations[l.ID] = current
+
+	return w.state.save()
+}
+
+func (w *AlertWatcher) emit(ctx context.Context, e AlertEvent) error {
+	if w.config.OnEvent != nil {
+		w.config.OnEvent(e)
+	}
+
+	if w.config.Events != nil {
+		select {
+		case w.config.Events <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// alertKey identifies an alert across polls. The URI alone is not enough, as some providers give
+// the same one to all the alerts of a region.
+func alertKey(a Alert) string {
+	return a.URI + "\n" + a.Title
+}
+
+func alertChanged(prev, a Alert) bool {
+	return prev.Time != a.Time ||
+		prev.Expires != a.Expires ||
+		prev.Severity != a.Severity ||
+		prev.Description != a.Description ||
+		!reflect.DeepEqual(prev.Regions, a.Regions)
+}
+
+func loadAlertWatcherState(path string) (*alertWatcherState, error) {
+	s := &alertWatcherState{path: path, Locations: make(map[string]map[string]Alert)}
+
+	if path == "" {
+		return s, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, err
+	}
+
+	if s.Locations == nil {
+		s.Locations = make(map[string]map[string]Alert)
+	}
+
+	return s, nil
+}
+
+func (s *alertWatcherState) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, content)
+}
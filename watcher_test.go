@@ -0,0 +1,272 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// HTTPClientAlertsMock answers with the alerts currently set, and fails for the latitudes in fail.
+type HTTPClientAlertsMock struct {
+	mu      sync.Mutex
+	alerts  Alerts
+	fail    map[string]bool
+	queries []string
+}
+
+func (c *HTTPClientAlertsMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queries = append(c.queries, req.URL.RawQuery)
+
+	for lat := range c.fail {
+		if strings.Contains(req.URL.Path, lat) {
+			return formatResponse(`{"code":500,"error":"Server Error"}`, 500, req)
+		}
+	}
+
+	content, err := json.Marshal(APIData{Timezone: "UTC", Alerts: c.alerts})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return formatResponse(string(content), 200, req)
+}
+
+func (c *HTTPClientAlertsMock) set(alerts ...Alert) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.alerts = alerts
+}
+
+type alertRecorder struct {
+	events []AlertEvent
+}
+
+func (r *alertRecorder) record(e AlertEvent) {
+	r.events = append(r.events, e)
+}
+
+func (r *alertRecorder) take() []string {
+	var got []string
+
+	for _, e := range r.events {
+		got = append(got, e.Location.ID+" "+e.Type.String()+" "+e.Alert.Title)
+	}
+
+	r.events = nil
+
+	return got
+}
+
+func assertEvents(t *testing.T, got []string, expected ...string) {
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected events %v, got %v", expected, got)
+	}
+}
+
+func newTestAlertWatcher(t *testing.T, client HTTPClient, statePath string, r *alertRecorder) *AlertWatcher {
+	api, err := NewAPI("test-secret", HTTPClientOption(client))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewAlertWatcher(api, AlertWatcherConfig{
+		Locations: []WatchLocation{
+			{ID: "boston", Lat: 42.3601, Lng: -71.0589},
+		},
+		AlertsOnly: true,
+		StatePath:  statePath,
+		OnEvent:    r.record,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.now = func() time.Time { return time.Unix(1000, 0) }
+
+	return w
+}
+
+func TestAlertWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "darksky-watcher")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	client := &HTTPClientAlertsMock{}
+	r := &alertRecorder{}
+	statePath := filepath.Join(dir, "alerts.json")
+	w := newTestAlertWatcher(t, client, statePath, r)
+	ctx := context.Background()
+
+	storm := Alert{Title: "Winter Storm Watch", URI: "https://alerts/1", Severity: SeverityWatch, Time: 900, Expires: 2000}
+	wind := Alert{Title: "Wind Advisory", URI: "https://alerts/2", Severity: SeverityAdvisory, Time: 900, Expires: 1500}
+
+	client.set(storm, wind)
+
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEvents(t, r.take(), "boston new Winter Storm Watch", "boston new Wind Advisory")
+
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEvents(t, r.take())
+
+	upgraded := storm
+	upgraded.Severity = SeverityWarning
+	client.set(upgraded, wind)
+
+	w.Poll(ctx)
+
+	events := r.events
+
+	assertEvents(t, r.take(), "boston updated Winter Storm Watch")
+
+	if events[0].Previous == nil || events[0].Previous.Severity != SeverityWatch {
+		t.Error("Updated events should give the previous alert")
+	}
+
+	// A restarted watcher does not report the alerts again.
+	w = newTestAlertWatcher(t, client, statePath, r)
+	client.set(upgraded)
+
+	w.Poll(ctx)
+
+	assertEvents(t, r.take(), "boston expired Wind Advisory")
+
+	w.now = func() time.Time { return time.Unix(2000, 0) }
+	w.Poll(ctx)
+
+	assertEvents(t, r.take(), "boston expired Winter Storm Watch")
+
+	if !strings.Contains(client.queries[0], "exclude=") {
+		t.Errorf("Alerts only watcher should exclude blocks, got query %s", client.queries[0])
+	}
+}
+
+func TestAlertWatcherErrors(t *testing.T) {
+	client := &HTTPClientAlertsMock{fail: map[string]bool{"45.5017": true}}
+	api, _ := NewAPI("test-secret", HTTPClientOption(client))
+	events := make(chan AlertEvent, 10)
+
+	var failed []string
+
+	w, err := NewAlertWatcher(api, AlertWatcherConfig{
+		Locations: []WatchLocation{
+			{ID: "boston", Lat: 42.3601, Lng: -71.0589},
+			{ID: "montreal", Lat: 45.5017, Lng: -73.5673},
+		},
+		Events:  events,
+		OnError: func(l WatchLocation, err error) { failed = append(failed, l.ID) },
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.set(Alert{Title: "Flood Watch", Time: 1})
+
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	assertInt(t, "events", int64(len(events)), 1)
+
+	e := <-events
+
+	assertString(t, "event location", e.Location.ID, "boston")
+	assertEvents(t, failed, "montreal")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := w.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAlertWatcherInterruptedDelivery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "darksky-watcher")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	client := &HTTPClientAlertsMock{}
+	api, _ := NewAPI("test-secret", HTTPClientOption(client))
+	statePath := filepath.Join(dir, "alerts.json")
+	events := make(chan AlertEvent)
+
+	w, err := NewAlertWatcher(api, AlertWatcherConfig{
+		Locations: []WatchLocation{{ID: "boston", Lat: 42.3601, Lng: -71.0589}},
+		StatePath: statePath,
+		Events:    events,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.set(Alert{Title: "Flood Watch", Time: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- w.Poll(ctx) }()
+
+	// Nobody receives the event, the delivery is interrupted.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	r := &alertRecorder{}
+	w = newTestAlertWatcher(t, client, statePath, r)
+
+	w.Poll(context.Background())
+
+	assertEvents(t, r.take(), "boston new Flood Watch")
+}
+
+func TestNewAlertWatcherInvalid(t *testing.T) {
+	api, _ := NewAPI("test-secret")
+	onEvent := func(AlertEvent) {}
+
+	configs := []AlertWatcherConfig{
+		{OnEvent: onEvent},
+		{Locations: []WatchLocation{{ID: "a"}}},
+		{Locations: []WatchLocation{{ID: "a"}, {ID: "a"}}, OnEvent: onEvent},
+		{Locations: []WatchLocation{{ID: "a"}}, OnEvent: onEvent, Interval: -time.Second},
+	}
+
+	for i, c := range configs {
+		if _, err := NewAlertWatcher(api, c); err != ErrInvalidWatcher {
+			t.Errorf("Config %d: expected ErrInvalidWatcher, got %v", i, err)
+		}
+	}
+}